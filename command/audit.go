@@ -0,0 +1,247 @@
+package command
+
+// 監査ログを検索する
+// 監査ログは add, remove, power, label を実行したときに記録される
+
+import (
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Audit struct {
+	since     time.Time
+	until     time.Time
+	vps       string
+	operation string
+	limit     int
+
+	*Command
+}
+
+func NewAudit() *Audit {
+	return &Audit{
+		Command: NewCommand(),
+	}
+}
+
+func (cmd *Audit) parseFlag() error {
+	var help bool
+	var since, until string
+	var err error
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVar(&since, "since", "", "")
+	fs.StringVar(&until, "until", "", "")
+	fs.StringVar(&cmd.vps, "vps", "", "")
+	fs.StringVar(&cmd.operation, "operation", "", "")
+	fs.IntVarP(&cmd.limit, "limit", "n", 0, "")
+	cmd.addOutputFlag(fs)
+
+	if err = fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if err = cmd.validateOutputFlag(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if since != "" {
+		if cmd.since, err = parseAuditTime(since, now); err != nil {
+			return err
+		}
+	}
+	if until != "" {
+		if cmd.until, err = parseAuditTime(until, now); err != nil {
+			return err
+		}
+	}
+
+	if cmd.operation != "" {
+		valid := false
+		for _, op := range lib.AuditOperations {
+			if op == cmd.operation {
+				valid = true
+			}
+		}
+		if !valid {
+			return errors.New(lib.T(`Undefined operation "%s". It should be one of "add", "remove", "power" or "label".`, cmd.operation))
+		}
+	}
+
+	if cmd.limit < 0 {
+		return errors.New(lib.T("--limit should be 0 or greater(0 means no limit)."))
+	}
+
+	return nil
+}
+
+func (cmd *Audit) Usage() {
+	printUsage("audit")
+}
+
+func (cmd *Audit) Run() error {
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	records, err := lib.ReadAuditRecords(cmd.config)
+	if err != nil {
+		return err
+	}
+
+	records = cmd.filter(records)
+
+	if cmd.template != nil {
+		items := []interface{}{}
+		for _, r := range records {
+			items = append(items, r)
+		}
+		return lib.RenderTemplate(os.Stdout, cmd.template, items...)
+
+	} else if cmd.output != lib.OutputTable {
+		return lib.Render(os.Stdout, cmd.output, records, auditTable(records, false))
+
+	} else {
+		opts := &lib.TableOptions{
+			MaxWidth: lib.TerminalWidth(os.Stdout),
+		}
+		return lib.RenderTable(os.Stdout, auditTable(records, true), opts)
+	}
+}
+
+// 条件に一致する監査ログを古い順に返す
+func (cmd *Audit) filter(records []*lib.AuditRecord) []*lib.AuditRecord {
+	result := []*lib.AuditRecord{}
+	for _, r := range records {
+		if !cmd.since.IsZero() && r.Time.Before(cmd.since) {
+			continue
+		}
+		if !cmd.until.IsZero() && r.Time.After(cmd.until) {
+			continue
+		}
+		if cmd.operation != "" && r.Operation != cmd.operation {
+			continue
+		}
+		if cmd.vps != "" && !matchGlob(cmd.vps, r.VpsId) && !matchGlob(cmd.vps, r.Label) {
+			continue
+		}
+		result = append(result, r)
+	}
+
+	// 時計が戻った場合などに備えて並べ直しておく
+	sort.Stable(auditByTime(result))
+
+	if cmd.limit > 0 && len(result) > cmd.limit {
+		result = result[len(result)-cmd.limit:]
+	}
+	return result
+}
+
+type auditByTime []*lib.AuditRecord
+
+func (a auditByTime) Len() int           { return len(a) }
+func (a auditByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a auditByTime) Less(i, j int) bool { return a[i].Time.Before(a[j].Time) }
+
+// --since と --until の時刻をパースする
+// 日付のほかに "24h" "30m" のような現在からの期間も指定できる
+func parseAuditTime(s string, now time.Time) (time.Time, error) {
+	if t, ok := parseFilterDate(s); ok {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, errors.New(lib.T(`Invalid time "%s". It should be like "2015-01-01", "2015-01-01 12:00" or "24h".`, s))
+}
+
+// 監査ログを出力するための表にする
+// displayがtrueの場合は表示用の(翻訳された)見出しと時刻にする
+func auditTable(records []*lib.AuditRecord, display bool) *lib.Table {
+	t := &lib.Table{
+		Header: []string{"time", "user", "account", "operation", "vpsId", "label", "params", "confirmation", "outcome", "error"},
+	}
+	if display {
+		t.Header = []string{lib.T("Time"), lib.T("User"), lib.T("Operation"), lib.T("VPS ID"), lib.T("Label"), lib.T("Parameters"), lib.T("Confirmation"), lib.T("Outcome")}
+	}
+
+	for _, r := range records {
+		keys := []string{}
+		for key := range r.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		params := []string{}
+		for _, key := range keys {
+			params = append(params, key+"="+r.Params[key])
+		}
+
+		if display {
+			t.Rows = append(t.Rows, []string{
+				r.Time.Local().Format("2006/01/02 15:04:05"), r.User, r.Operation, r.VpsId, r.Label,
+				strings.Join(params, " "), r.Confirmation, r.Outcome,
+			})
+		} else {
+			t.Rows = append(t.Rows, []string{
+				formatDate(r.Time), r.User, r.Account, r.Operation, r.VpsId, r.Label,
+				strings.Join(params, " "), r.Confirmation, r.Outcome, r.Error,
+			})
+		}
+	}
+	return t
+}
+
+// 監査ログを作成する
+// VPSを指定する操作の場合は、操作の前にラベルを取得しておく
+func (cmd *Vps) newAuditRecord(operation string, vmId string) *lib.AuditRecord {
+	record := lib.NewAuditRecord(cmd.config, operation)
+	record.VpsId = vmId
+
+	if vmId != "" {
+		if vm := (&VpsList{Vps: cmd}).Vm(vmId); vm != nil {
+			record.Label = vm.Label
+		}
+	}
+	return record
+}
+
+// -f の有無から確認の方法を返す
+func auditConfirmation(force bool) string {
+	if force {
+		return lib.AuditConfirmForce
+	}
+	return lib.AuditConfirmPrompt
+}
+
+// 操作の結果を監査ログに記録する
+// 記録に失敗しても操作の結果は変えずに警告を出力するだけにする
+func (cmd *Vps) audit(record *lib.AuditRecord, err error) {
+	// ドライランでは何も変更していないので記録しない
+	if GetGlobalOptions().DryRun {
+		return
+	}
+
+	record.Outcome = ExitCodeOf(err).Name()
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if werr := lib.WriteAuditRecord(cmd.config, record); werr != nil {
+		lib.GetLogInstance().Warn(lib.T("Could not write the audit log: %s", werr))
+	}
+}
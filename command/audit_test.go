@@ -0,0 +1,89 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	"testing"
+	"time"
+)
+
+func testAuditRecords() []*lib.AuditRecord {
+	return []*lib.AuditRecord{
+		{Time: time.Date(2015, 1, 10, 9, 0, 0, 0, time.Local), Operation: lib.AuditAdd, Outcome: "ok"},
+		{Time: time.Date(2015, 1, 12, 9, 0, 0, 0, time.Local), Operation: lib.AuditLabel, VpsId: "aaa", Label: "web-01", Outcome: "ok"},
+		{Time: time.Date(2015, 1, 11, 9, 0, 0, 0, time.Local), Operation: lib.AuditPower, VpsId: "aaa", Label: "web-01", Outcome: "declined"},
+		{Time: time.Date(2015, 1, 13, 9, 0, 0, 0, time.Local), Operation: lib.AuditRemove, VpsId: "bbb", Label: "db-01", Outcome: "ok"},
+	}
+}
+
+func TestAuditFilter(t *testing.T) {
+	date := func(d int) time.Time { return time.Date(2015, 1, d, 0, 0, 0, 0, time.Local) }
+
+	tests := []struct {
+		cmd  *Audit
+		want []string
+	}{
+		{&Audit{}, []string{"add", "power", "label", "remove"}},
+		{&Audit{since: date(11)}, []string{"power", "label", "remove"}},
+		{&Audit{since: date(11), until: date(13)}, []string{"power", "label"}},
+		{&Audit{vps: "aaa"}, []string{"power", "label"}},
+		{&Audit{vps: "db-*"}, []string{"remove"}},
+		{&Audit{operation: "power"}, []string{"power"}},
+		{&Audit{limit: 2}, []string{"label", "remove"}},
+	}
+
+	for i, test := range tests {
+		records := test.cmd.filter(testAuditRecords())
+		if len(records) != len(test.want) {
+			t.Errorf("%d: got %d records, want %v", i, len(records), test.want)
+			continue
+		}
+		for j, r := range records {
+			if r.Operation != test.want[j] {
+				t.Errorf("%d: records[%d] = %s, want %s", i, j, r.Operation, test.want[j])
+			}
+		}
+	}
+}
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2015, 1, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"2015-01-01", time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"2015-01-01 12:30", time.Date(2015, 1, 1, 12, 30, 0, 0, time.Local)},
+		{"24h", time.Date(2015, 1, 9, 12, 0, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		got, err := parseAuditTime(test.s, now)
+		if err != nil {
+			t.Errorf("%s: %s", test.s, err)
+		} else if !got.Equal(test.want) {
+			t.Errorf("%s: got %s, want %s", test.s, got, test.want)
+		}
+	}
+
+	if _, err := parseAuditTime("yesterday", now); err == nil {
+		t.Error("yesterday: should be an error")
+	}
+}
+
+func TestAuditParamsRedacted(t *testing.T) {
+	info := &VpsAddInformation{
+		PlanType:     PlanTypeBasic,
+		Plan:         Plan4G,
+		Template:     TemplateDefault1,
+		RootPassword: "secret-password",
+		SshKeyNo:     1,
+	}
+
+	params := lib.RedactParams(info.auditParams())
+	if params["password"] == "secret-password" {
+		t.Error("password is not redacted")
+	}
+	if params["type"] != "basic" || params["plan"] != "4" || params["image"] != "centos" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
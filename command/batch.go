@@ -0,0 +1,323 @@
+package command
+
+// ファイルに書いたコマンドを順番に実行する
+// コマンドはシェル(conoha shell)と同じく一つのセッションで実行する
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 変数への代入(NAME=value、NAME=$(command))
+var batchAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+type Batch struct {
+	file            string
+	continueOnError bool
+
+	// 変数。見つからない場合は環境変数を使う
+	vars map[string]string
+
+	*Shell
+}
+
+func NewBatch() *Batch {
+	return &Batch{
+		vars:  map[string]string{},
+		Shell: NewShell(),
+	}
+}
+
+func (cmd *Batch) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.BoolVar(&cmd.continueOnError, "continue-on-error", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if len(fs.Args()) > 2 {
+		return errors.New(lib.T("Too many arguments."))
+	}
+	cmd.file = fs.Arg(1)
+	return nil
+}
+
+func (cmd *Batch) Usage() {
+	printUsage("batch")
+}
+
+// バッチの1行
+type batchLine struct {
+	No   int
+	Text string
+
+	// 代入する変数名
+	Var string
+
+	// 実行するコマンド。空の場合はValueを変数に代入する
+	Command string
+	Value   string
+}
+
+// 1行の実行結果
+type batchResult struct {
+	Line    *batchLine
+	Err     error
+	Skipped bool
+}
+
+func (cmd *Batch) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	lines, err := cmd.readLines()
+	if err != nil {
+		return err
+	}
+
+	// 実行を始める前に、全ての行の書式とコマンド名を確認する
+	for _, line := range lines {
+		if err = validateBatchLine(line); err != nil {
+			return &UsageError{Err: &BatchLineError{No: line.No, Err: err}}
+		}
+	}
+
+	// バッチを開始する前にログインは確認済み
+	cmd.loginChecked = time.Now()
+	defer shareConfig(cmd.config)()
+
+	results := []*batchResult{}
+	failed := false
+	for _, line := range lines {
+		result := &batchResult{Line: line}
+		results = append(results, result)
+
+		if failed && !cmd.continueOnError {
+			result.Skipped = true
+			continue
+		}
+
+		result.Err = cmd.runLine(line)
+		if _, ok := result.Err.(*ShowUsageError); ok {
+			result.Err = nil
+		}
+		if result.Err != nil {
+			failed = true
+			PrintError(os.Stderr, GetGlobalOptions().ErrorFormat, &BatchLineError{No: line.No, Err: result.Err})
+		}
+
+		if cmd.loggedOut {
+			break
+		}
+	}
+
+	writeBatchSummary(os.Stderr, results)
+	return batchError(results)
+}
+
+// ファイル(指定がない場合や"-"の場合は標準入力)から行を読む
+// 空行と#で始まるコメントは読み飛ばす
+func (cmd *Batch) readLines() ([]*batchLine, error) {
+	var r io.Reader = os.Stdin
+	if cmd.file != "" && cmd.file != "-" {
+		file, err := os.Open(cmd.file)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	lines := []*batchLine{}
+	scanner := bufio.NewScanner(r)
+	for no := 1; scanner.Scan(); no++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		line := &batchLine{No: no, Text: text, Command: text}
+		if m := batchAssignment.FindStringSubmatch(text); m != nil {
+			line.Var = m[1]
+			if strings.HasPrefix(m[2], "$(") && strings.HasSuffix(m[2], ")") {
+				line.Command = strings.TrimSpace(m[2][2 : len(m[2])-1])
+			} else {
+				line.Command = ""
+				line.Value = m[2]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// 行の書式とコマンド名が正しいかを確認する
+// 変数の値は実行するまで分からないので空文字列として扱う
+func validateBatchLine(line *batchLine) error {
+	empty := func(string) (string, error) { return "", nil }
+
+	if line.Command == "" {
+		_, err := splitWords(line.Value, empty)
+		return err
+	}
+
+	args, err := splitWords(line.Command, empty)
+	if err != nil {
+		return err
+	} else if len(args) == 0 {
+		return errors.New(lib.T("No command is specified."))
+	}
+
+	if LookupCommand(args[0]) == nil && LookupPlugin(args[0]) == nil {
+		return errors.New(lib.T(`Undefined command "%s".`, args[0]))
+	}
+	return nil
+}
+
+// 1行を実行する
+func (cmd *Batch) runLine(line *batchLine) error {
+	if line.Command == "" {
+		words, err := splitWords(line.Value, cmd.expand)
+		if err != nil {
+			return err
+		}
+		cmd.vars[line.Var] = strings.Join(words, " ")
+		return nil
+	}
+
+	args, err := splitWords(line.Command, cmd.expand)
+	if err != nil {
+		return err
+	}
+
+	if line.Var == "" {
+		return cmd.execute(args)
+	}
+
+	// 標準出力を変数に代入する
+	out, err := captureStdout(func() error {
+		return cmd.execute(args)
+	})
+	cmd.vars[line.Var] = strings.TrimRight(out, "\r\n")
+	return err
+}
+
+// 変数の値を返す。バッチの変数がない場合は環境変数を使う
+func (cmd *Batch) expand(name string) (string, error) {
+	if value, ok := cmd.vars[name]; ok {
+		return value, nil
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return "", errors.New(lib.T(`Variable "%s" is not defined.`, name))
+}
+
+// fnを実行して、その間に標準出力に出力された内容を返す
+func captureStdout(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	out := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		r.Close()
+		out <- string(b)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	err = fn()
+	os.Stdout = stdout
+	w.Close()
+
+	return <-out, err
+}
+
+// 各行の結果を出力する
+func writeBatchSummary(w io.Writer, results []*batchResult) {
+	fmt.Fprintln(w, lib.T("Summary:"))
+	for _, result := range results {
+		var status string
+		switch {
+		case result.Skipped:
+			status = "skipped"
+		case result.Err != nil:
+			status = ExitCodeOf(result.Err).Name()
+		default:
+			status = ExitCodeOK.Name()
+		}
+		fmt.Fprintf(w, "  %4d  %-10s %s\n", result.Line.No, status, result.Line.Text)
+	}
+}
+
+// 失敗した行があればBatchErrorを返す
+func batchError(results []*batchResult) error {
+	e := &BatchError{Total: len(results)}
+	for _, result := range results {
+		if result.Err == nil || result.Skipped {
+			continue
+		}
+		if e.Failed == 0 {
+			e.Code = ExitCodeOf(result.Err)
+		}
+		e.Failed++
+	}
+
+	if e.Failed == 0 {
+		return nil
+	}
+	return e
+}
+
+// 行番号付きのエラー
+type BatchLineError struct {
+	No  int
+	Err error
+}
+
+func (e *BatchLineError) Error() string {
+	return lib.T("line %d: %s", e.No, e.Err.Error())
+}
+
+func (e *BatchLineError) ExitCode() ExitCode {
+	return ExitCodeOf(e.Err)
+}
+
+// バッチの一部の行が失敗した場合のエラー
+// 終了コードは最初に失敗した行の終了コードにする
+type BatchError struct {
+	Failed int
+	Total  int
+	Code   ExitCode
+}
+
+func (e *BatchError) Error() string {
+	return lib.T("%d of %d lines failed.", e.Failed, e.Total)
+}
+
+func (e *BatchError) ExitCode() ExitCode {
+	return e.Code
+}
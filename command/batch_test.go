@@ -0,0 +1,118 @@
+package command
+
+import (
+	"bytes"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWordsExpand(t *testing.T) {
+	vars := map[string]string{"ID": "f648a6646b7e7d91", "LABEL": "web server"}
+	expand := func(name string) (string, error) {
+		return vars[name], nil
+	}
+
+	tests := map[string][]string{
+		"stat $ID":                   {"stat", "f648a6646b7e7d91"},
+		"label ${ID} -l $LABEL":      {"label", "f648a6646b7e7d91", "-l", "web server"},
+		`label $ID -l "${LABEL}-01"`: {"label", "f648a6646b7e7d91", "-l", "web server-01"},
+		`list --format '$ID'`:        {"list", "--format", "$ID"},
+		`list --format \$ID $`:       {"list", "--format", "$ID", "$"},
+	}
+	for line, want := range tests {
+		got, err := splitWords(line, expand)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q [%v]", line, got, want, err)
+		}
+	}
+}
+
+func TestBatchReadLines(t *testing.T) {
+	file, err := ioutil.TempFile("", "conoha-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	file.WriteString("# comment\n\nLABEL=web-01\nID=$(list --format '{{.Id}}')\nlabel $ID -l $LABEL\n")
+	file.Close()
+
+	cmd := &Batch{file: file.Name()}
+	lines, err := cmd.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*batchLine{
+		{No: 3, Text: "LABEL=web-01", Var: "LABEL", Value: "web-01"},
+		{No: 4, Text: "ID=$(list --format '{{.Id}}')", Var: "ID", Command: "list --format '{{.Id}}'"},
+		{No: 5, Text: "label $ID -l $LABEL", Command: "label $ID -l $LABEL"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %+v", lines)
+	}
+
+	for _, line := range lines {
+		if err := validateBatchLine(line); err != nil {
+			t.Errorf("%s: %s", line.Text, err)
+		}
+	}
+	for _, text := range []string{"unknown $ID", `label -l "web`} {
+		if err := validateBatchLine(&batchLine{Text: text, Command: text}); err == nil {
+			t.Errorf("%s: should be invalid.", text)
+		}
+	}
+}
+
+func TestBatchRunLine(t *testing.T) {
+	defer shareConfig(&lib.Config{})()
+
+	cmd := &Batch{vars: map[string]string{}, Shell: &Shell{}}
+
+	// 出力を変数に代入する
+	lines := []*batchLine{
+		{Var: "NAME", Value: "version"},
+		{Var: "USAGE", Command: "help $NAME"},
+	}
+	for _, line := range lines {
+		if err := cmd.runLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.Contains(cmd.vars["USAGE"], "conoha version") {
+		t.Errorf("output should be assigned. [%s]", cmd.vars["USAGE"])
+	}
+
+	if err := cmd.runLine(&batchLine{Command: "help $UNDEFINED_VARIABLE"}); err == nil {
+		t.Errorf("undefined variable should be error.")
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	results := []*batchResult{
+		{Line: &batchLine{No: 1, Text: "list"}},
+		{Line: &batchLine{No: 2, Text: "stat web-01"}, Err: &VpsNotFoundError{VmId: "web-01"}},
+		{Line: &batchLine{No: 3, Text: "power web-01 -c boot"}, Skipped: true},
+	}
+
+	err := batchError(results)
+	if e, ok := err.(*BatchError); !ok || e.Failed != 1 || ExitCodeOf(err) != ExitCodeNotFound {
+		t.Errorf("got %#v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	writeBatchSummary(buf, results)
+	for _, s := range []string{"   1  ok         list\n", "   2  not_found  stat web-01\n", "   3  skipped    power web-01 -c boot\n"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("summary should contain [%s]\n%s", s, buf.String())
+		}
+	}
+
+	if err := batchError(results[:1]); err != nil {
+		t.Errorf("should be nil. [%v]", err)
+	}
+}
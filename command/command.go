@@ -1,17 +1,55 @@
 package command
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
+	flag "github.com/ogier/pflag"
+	"io"
+	"net"
+	"text/template"
 )
 
+// 終了コード
+// 値はスクリプトから参照されるので、変更せずに末尾に追加すること
 type ExitCode int
 
 const (
-	ExitCodeOK = iota
-	ExitCodeNG
+	ExitCodeOK       ExitCode = iota // 正常終了
+	ExitCodeNG                       // その他のエラー
+	ExitCodeTimeout                  // タイムアウト
+	ExitCodeNotFound                 // VPSなどが見つからない
+	ExitCodeUsage                    // コマンドライン引数が正しくない
+	ExitCodeAuth                     // ログインしていない、もしくはログインに失敗した
+	ExitCodeMarkup                   // コントロールパネルのHTMLをパースできない
+	ExitCodeDeclined                 // 確認でキャンセルされた
 )
 
+var exitCodeNames = map[ExitCode]string{
+	ExitCodeOK:       "ok",
+	ExitCodeNG:       "error",
+	ExitCodeTimeout:  "timeout",
+	ExitCodeNotFound: "not_found",
+	ExitCodeUsage:    "usage",
+	ExitCodeAuth:     "auth",
+	ExitCodeMarkup:   "markup",
+	ExitCodeDeclined: "declined",
+}
+
+// --error-format json で出力するエラーの種類
+func (c ExitCode) Name() string {
+	if name, ok := exitCodeNames[c]; ok {
+		return name
+	}
+	return "error"
+}
+
+// 終了コードを指定したいエラーはこのインターフェイスを実装する
+type ExitCoder interface {
+	ExitCode() ExitCode
+}
+
 type Commander interface {
 	// コマンドライン引数を処理する
 	parseFlag() error
@@ -29,6 +67,35 @@ type Commander interface {
 type Command struct {
 	config  *lib.Config
 	browser *cpanel.Browser
+
+	// 出力フォーマット(lib.Output*定数)
+	output string
+
+	// --format で指定されたテンプレート
+	format   string
+	template *template.Template
+}
+
+// 出力フォーマットのオプション(-o と --format)を追加する
+func (c *Command) addOutputFlag(fs *flag.FlagSet) {
+	// デフォルトは全体のオプション(--output)で変更できる
+	fs.StringVarP(&c.output, "output", "o", GetGlobalOptions().Output, "")
+	fs.StringVar(&c.format, "format", "", "")
+}
+
+// 出力フォーマットのオプションをチェックする
+// フラグのパース後に呼ぶこと
+func (c *Command) validateOutputFlag() (err error) {
+	if err = lib.ValidateOutputFormat(c.output); err != nil {
+		return err
+	}
+
+	if c.format != "" {
+		if c.template, err = lib.ParseTemplate(c.format); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Commandの実行が完了したときに呼ばれる関数。忘れずdeferすること。
@@ -36,6 +103,11 @@ type Command struct {
 func (c *Command) Shutdown() {
 	log := lib.GetLogInstance()
 
+	// シェルの中で実行したコマンドは、シェルの終了時にまとめて書き込む
+	if sharedConfig != nil {
+		return
+	}
+
 	c.config.Sid = c.browser.BrowserInfo.Sid()
 	c.config.Write()
 
@@ -43,13 +115,30 @@ func (c *Command) Shutdown() {
 }
 
 func NewCommand() *Command {
+	// シェルの中ではシェルの設定とセッションをそのまま使う
+	if sharedConfig != nil {
+		browser := cpanel.NewBrowser()
+		browser.DryRun = GetGlobalOptions().DryRun
+		return &Command{
+			config:  sharedConfig,
+			browser: browser,
+		}
+	}
+
 	// Configを作成
 	c := &lib.Config{}
 	c.Read()
 
+	// 存在しないプロファイルはParseGlobalFlags()でエラーにしている
+	c.UseProfile(GetGlobalOptions().Profile)
+
+	// キャッシュの保存先はアカウントごとに分ける
+	lib.GetCacheInstance().Configure(c)
+
 	// ブラウザを作成してセッションIDをセットする
 	browser := cpanel.NewBrowser()
 	browser.BrowserInfo.FixSid(c.Sid)
+	browser.DryRun = GetGlobalOptions().DryRun
 
 	// コマンドを作成する
 	cmd := &Command{
@@ -68,3 +157,151 @@ type ShowUsageError struct {
 func (e ShowUsageError) Error() string {
 	return e.s
 }
+
+// VPSが見つからない場合のエラー
+type VpsNotFoundError struct {
+	VmId string
+}
+
+func (e *VpsNotFoundError) Error() string {
+	if e.VmId == "" {
+		return lib.T("VPS not found.")
+	}
+	return lib.T("VPS not found(id=%s).", e.VmId)
+}
+
+func (e *VpsNotFoundError) ExitCode() ExitCode {
+	return ExitCodeNotFound
+}
+
+// VPS以外のもの(スナップショットやSSH鍵など)が見つからない場合のエラー
+type NotFoundError struct {
+	s string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.s
+}
+
+func (e *NotFoundError) ExitCode() ExitCode {
+	return ExitCodeNotFound
+}
+
+// コマンドライン引数が正しくない場合のエラー
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) ExitCode() ExitCode {
+	return ExitCodeUsage
+}
+
+// コントロールパネルへのリクエストが失敗した場合のエラー
+// parseFlag()の中でVPSの一覧を取得する場合に、通信やHTMLの解析のエラーを引数の誤りと区別するために使う
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) ExitCode() ExitCode {
+	return ExitCodeOf(e.Err)
+}
+
+// 終了コードを持たないエラーをRequestErrorにする
+func requestError(err error) error {
+	switch err.(type) {
+	case nil, ExitCoder:
+		return err
+	}
+	return &RequestError{Err: err}
+}
+
+// parseFlag()が返したエラーをUsageErrorにする
+// VPSの選択中に起きたエラー(ログインの失敗、通信のエラー、選択の取り消しなど)は終了コードを持っているのでそのまま返す
+func usageError(err error) error {
+	switch err.(type) {
+	case nil, *ShowUsageError, ExitCoder:
+		return err
+	}
+	return &UsageError{Err: err}
+}
+
+// ログインしていない、もしくはログインに失敗した場合のエラー
+type AuthError struct {
+	s string
+}
+
+func (e *AuthError) Error() string {
+	if e.s == "" {
+		return lib.T("Session is timed out. Please log in.")
+	}
+	return e.s
+}
+
+func (e *AuthError) ExitCode() ExitCode {
+	return ExitCodeAuth
+}
+
+// 確認で"y"以外が入力された場合のエラー
+type DeclinedError struct {
+}
+
+func (e *DeclinedError) Error() string {
+	return lib.T("Canceled.")
+}
+
+func (e *DeclinedError) ExitCode() ExitCode {
+	return ExitCodeDeclined
+}
+
+// エラーに対応する終了コードを返す
+func ExitCodeOf(err error) ExitCode {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	switch e := err.(type) {
+	case *ShowUsageError:
+		return ExitCodeOK
+	case ExitCoder:
+		return e.ExitCode()
+	case *cpanel.MarkupError:
+		return ExitCodeMarkup
+	case net.Error:
+		if e.Timeout() {
+			return ExitCodeTimeout
+		}
+	}
+	return ExitCodeNG
+}
+
+// --error-format json で出力するエラー
+type errorJson struct {
+	Code    ExitCode `json:"code"`
+	Kind    string   `json:"kind"`
+	Message string   `json:"message"`
+}
+
+// エラーをformat("text" か "json")で出力する
+// textの場合はログとして出力する
+func PrintError(w io.Writer, format string, err error) {
+	if format != "json" {
+		lib.GetLogInstance().Error(err)
+		return
+	}
+
+	code := ExitCodeOf(err)
+	b, _ := json.Marshal(&errorJson{
+		Code:    code,
+		Kind:    code.Name(),
+		Message: err.Error(),
+	})
+	fmt.Fprintln(w, string(b))
+}
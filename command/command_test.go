@@ -0,0 +1,44 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"testing"
+)
+
+func TestExitCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		code ExitCode
+	}{
+		{nil, ExitCodeOK},
+		{&ShowUsageError{}, ExitCodeOK},
+		{errors.New("foo"), ExitCodeNG},
+		{&WaitTimeoutError{}, ExitCodeTimeout},
+		{&VpsNotFoundError{VmId: "aaa"}, ExitCodeNotFound},
+		{usageError(errors.New("Not enough arguments.")), ExitCodeUsage},
+		{&AuthError{}, ExitCodeAuth},
+		{cpanel.NewMarkupError("TrID not exists"), ExitCodeMarkup},
+		{&DeclinedError{}, ExitCodeDeclined},
+		{usageError(&DeclinedError{}), ExitCodeDeclined},
+		{usageError(requestError(cpanel.NewMarkupError("TrID not exists"))), ExitCodeMarkup},
+		{usageError(requestError(errors.New("connection refused"))), ExitCodeNG},
+	}
+
+	for _, test := range tests {
+		if code := ExitCodeOf(test.err); code != test.code {
+			t.Errorf("exit code of %#v should be %d. [%d]", test.err, test.code, code)
+		}
+	}
+}
+
+func TestPrintErrorJson(t *testing.T) {
+	buf := &bytes.Buffer{}
+	PrintError(buf, "json", &VpsNotFoundError{VmId: "aaa"})
+
+	expected := `{"code":3,"kind":"not_found","message":"VPS not found(id=aaa)."}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("wrong output. [%s]", buf.String())
+	}
+}
@@ -0,0 +1,312 @@
+package command
+
+// シェルの補完
+// conoha completion bash|zsh|fish で補完スクリプトを出力する
+// 補完スクリプトは conoha __complete <入力中の単語...> を実行して候補を取得する
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"strings"
+)
+
+// 補完の候補を返すコマンドの名前
+const CompleteCommandName = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for conoha
+# eval "$(conoha completion bash)"
+_conoha() {
+    local IFS=$'\n'
+    COMPREPLY=($(conoha __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1))
+}
+# --output=json のような形式を一つの単語として扱う
+COMP_WORDBREAKS=${COMP_WORDBREAKS//=/}
+complete -o default -F _conoha conoha
+`,
+
+	"zsh": `#compdef conoha
+# zsh completion for conoha
+# eval "$(conoha completion zsh)"
+_conoha() {
+    local -a lines candidates
+    local line
+    lines=("${(@f)$(conoha __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    for line in $lines; do
+        [[ -z $line ]] && continue
+        if [[ $line == *$'\t'* ]]; then
+            candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            candidates+=("${line//:/\\:}")
+        fi
+    done
+    if (( ${#candidates} )); then
+        _describe 'conoha' candidates
+    else
+        _files
+    fi
+}
+compdef _conoha conoha
+`,
+
+	"fish": `# fish completion for conoha
+# conoha completion fish | source
+function __conoha_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    conoha __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c conoha -f -a '(__conoha_complete)'
+`,
+}
+
+type Completion struct {
+	shell string
+
+	*Command
+}
+
+func NewCompletion() *Completion {
+	return &Completion{
+		Command: NewCommand(),
+	}
+}
+
+func (cmd *Completion) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	cmd.shell = fs.Arg(1)
+	if _, ok := completionScripts[cmd.shell]; !ok {
+		fs.Usage()
+		return errors.New(lib.T(`Undefined shell "%s". It should be "bash", "zsh" or "fish".`, cmd.shell))
+	}
+	return nil
+}
+
+func (cmd *Completion) Usage() {
+	printUsage("completion")
+}
+
+func (cmd *Completion) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	fmt.Print(completionScripts[cmd.shell])
+	return nil
+}
+
+// 補完の候補を出力する
+// 候補は1行に1つで、説明がある場合はタブで区切って後ろに付ける
+type Complete struct {
+	*Vps
+}
+
+func NewComplete() *Complete {
+	return &Complete{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *Complete) parseFlag() error {
+	return nil
+}
+
+func (cmd *Complete) Usage() {
+}
+
+func (cmd *Complete) Run() error {
+	for _, c := range completeWords(os.Args[2:], cmd.vpsCandidates) {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+// VPS-IDの候補
+// 補完を速くするため、有効期間を過ぎていてもキャッシュされた一覧を使う
+func (cmd *Complete) vpsCandidates() []string {
+	var servers []*Vm
+	cache := lib.GetCacheInstance()
+	if !cache.GetStale("list", &servers) && !cache.GetStale("list-status", &servers) {
+		// キャッシュがない場合だけ取得する(取得した一覧はキャッシュされる)
+		var err error
+		if servers, err = NewVpsList().List(false); err != nil {
+			return nil
+		}
+	}
+
+	candidates := []string{}
+	for _, vm := range servers {
+		candidates = append(candidates, vm.Id+"\t"+vm.Label)
+	}
+	return candidates
+}
+
+// 入力中の単語から補完の候補を返す
+// wordsの最後が補完する単語(空文字列の場合もある)
+// VPS-IDの候補は必要な場合だけvpsを呼んで取得する
+func completeWords(words []string, vps func() []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	prev := words[:len(words)-1]
+
+	// サブコマンドと、サブコマンドの後の引数の数を調べる
+	var info *CommandInfo
+	args := 0
+	for i := 0; i < len(prev); i++ {
+		w := prev[i]
+		if w == "--" {
+			return nil
+		}
+
+		if strings.HasPrefix(w, "-") {
+			// 値を取るオプションは次の単語を読み飛ばす
+			if f := findFlagInfo(commandFlags(info), w); f != nil && f.Value && !strings.Contains(w, "=") {
+				i++
+			}
+			continue
+		}
+
+		if info == nil {
+			if info = LookupCommand(w); info == nil {
+				return nil
+			}
+			continue
+		}
+		args++
+	}
+	fs := commandFlags(info)
+
+	// オプションの値
+	if len(prev) > 0 {
+		if f := findFlagInfo(fs, prev[len(prev)-1]); f != nil && f.Value && !strings.Contains(prev[len(prev)-1], "=") {
+			return filterCandidates(f.Values, "", cur)
+		}
+	}
+	if strings.HasPrefix(cur, "--") && strings.Contains(cur, "=") {
+		name := cur[:strings.Index(cur, "=")]
+		if f := findFlagInfo(fs, name); f != nil {
+			return filterCandidates(f.Values, name+"=", cur)
+		}
+		return nil
+	}
+
+	// オプション名
+	if strings.HasPrefix(cur, "-") {
+		// --output は全体のオプションとコマンドのオプションの両方にある
+		names := []string{}
+		seen := map[string]bool{}
+		add := func(name string) {
+			if !seen[name] {
+				names = append(names, name)
+				seen[name] = true
+			}
+		}
+		for _, f := range fs {
+			if f.Name != "" {
+				add("--" + f.Name)
+			}
+			if f.Short != "" {
+				add("-" + f.Short)
+			}
+		}
+		return filterCandidates(names, "", cur)
+	}
+
+	// サブコマンド
+	if info == nil {
+		return filterCandidates(commandCandidates(), "", cur)
+	}
+
+	// 引数
+	if args > 0 {
+		return nil
+	}
+	switch {
+	case info.Name == "help":
+		return filterCandidates(commandCandidates(), "", cur)
+	case info.Name == "completion":
+		return filterCandidates(completionShells, "", cur)
+	case info.VpsArg:
+		return filterCandidates(vps(), "", cur)
+	}
+	return nil
+}
+
+// サブコマンドで使えるオプション(全体のオプションを含む)
+func commandFlags(info *CommandInfo) []*FlagInfo {
+	if info == nil {
+		return globalFlags
+	}
+	return flags(globalFlags, info.Flags)
+}
+
+// "--name" "--name=value" "-n" からオプションを探す
+func findFlagInfo(fs []*FlagInfo, word string) *FlagInfo {
+	if i := strings.Index(word, "="); i >= 0 {
+		word = word[:i]
+	}
+
+	for _, f := range fs {
+		if strings.HasPrefix(word, "--") {
+			if f.Name != "" && word == "--"+f.Name {
+				return f
+			}
+		} else if f.Short != "" && word == "-"+f.Short {
+			return f
+		}
+	}
+	return nil
+}
+
+// 使い方に表示するコマンドと別名(説明付き)
+func commandCandidates() []string {
+	candidates := []string{}
+	for _, info := range Commands() {
+		if info.Hidden {
+			continue
+		}
+		candidates = append(candidates, info.Name+"\t"+lib.T(info.Summary))
+		for _, alias := range info.Aliases {
+			candidates = append(candidates, alias+"\t"+lib.T(info.Summary))
+		}
+	}
+	return candidates
+}
+
+// curで始まる候補を返す
+// 候補の先頭にはprefixを付ける(--name=value の形式の場合)
+// 説明(タブより後ろ)は比較に使わない
+func filterCandidates(candidates []string, prefix string, cur string) []string {
+	result := []string{}
+	for _, c := range candidates {
+		c = prefix + c
+		value := c
+		if i := strings.Index(c, "\t"); i >= 0 {
+			value = c[:i]
+		}
+		if strings.HasPrefix(value, cur) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
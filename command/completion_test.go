@@ -0,0 +1,63 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	vps := func() []string {
+		return []string{"f648a6646b7e7d91\tweb-01", "0c1d7e05fd3f3c6a\tdb-01"}
+	}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"st"}, []string{"stat"}},
+		{[]string{"sh"}, []string{"shell", "show"}},
+		{[]string{"stat", ""}, []string{"f648a6646b7e7d91", "0c1d7e05fd3f3c6a"}},
+		{[]string{"rm", "f6"}, []string{"f648a6646b7e7d91"}},
+		{[]string{"stat", "f648a6646b7e7d91", ""}, nil},
+		{[]string{"power", "-c", ""}, []string{"boot", "reboot", "shutdown", "stop"}},
+		{[]string{"power", "--command", "re"}, []string{"reboot"}},
+		{[]string{"add", "--image=w"}, []string{"--image=wordpress", "--image=windows2012", "--image=windows2008"}},
+		{[]string{"--lang", "ja", "add", "-i", "c"}, []string{"centos"}},
+		{[]string{"stat", "--output", "j"}, []string{"json"}},
+		{[]string{"stat", "-o", "json", ""}, []string{"f648a6646b7e7d91", "0c1d7e05fd3f3c6a"}},
+		{[]string{"stat", "--inc"}, []string{"--include-ipv6"}},
+		{[]string{"list", "--ou"}, []string{"--output"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"help", "pow"}, []string{"power"}},
+		{[]string{"unknown", ""}, nil},
+	}
+
+	for _, test := range tests {
+		got := completeWords(test.words, vps)
+
+		// 説明は比較しない
+		values := []string{}
+		for _, c := range got {
+			values = append(values, strings.SplitN(c, "\t", 2)[0])
+		}
+		if got == nil {
+			values = nil
+		}
+
+		if !reflect.DeepEqual(values, test.want) {
+			t.Errorf("%q: got %q, want %q", test.words, values, test.want)
+		}
+	}
+}
+
+func TestCompleteWordsNoVpsFetch(t *testing.T) {
+	vps := func() []string {
+		t.Error("VPS list should not be fetched")
+		return nil
+	}
+
+	completeWords([]string{"list", ""}, vps)
+	completeWords([]string{"stat", "-"}, vps)
+	completeWords([]string{""}, vps)
+}
@@ -0,0 +1,304 @@
+package command
+
+// manページとMarkdownのリファレンスを作成する
+// conoha docs --man DIR --markdown DIR
+// 内容は使い方(-h)と同じくCommandInfoの定義から作る
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Docs struct {
+	// manページを作成するディレクトリ
+	manDir string
+
+	// Markdownを作成するディレクトリ
+	markdownDir string
+
+	*Command
+}
+
+func NewDocs() *Docs {
+	return &Docs{
+		Command: NewCommand(),
+	}
+}
+
+func (cmd *Docs) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.manDir, "man", "", "", "")
+	fs.StringVarP(&cmd.markdownDir, "markdown", "", "", "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if cmd.manDir == "" && cmd.markdownDir == "" {
+		fs.Usage()
+		return errors.New(lib.T("Either --man or --markdown is required."))
+	}
+	return nil
+}
+
+func (cmd *Docs) Usage() {
+	printUsage("docs")
+}
+
+func (cmd *Docs) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	if cmd.manDir != "" {
+		if err := writeDocs(cmd.manDir, manFileName, writeMan); err != nil {
+			return err
+		}
+	}
+	if cmd.markdownDir != "" {
+		if err := writeDocs(cmd.markdownDir, markdownFileName, writeMarkdown); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conohaと全てのコマンドのドキュメントをdirに作成する
+// 作成したファイルのパスを標準出力に表示する
+func writeDocs(dir string, filename func(info *CommandInfo) string, write func(w io.Writer, info *CommandInfo)) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, info := range append([]*CommandInfo{conohaInfo}, visibleCommands()...) {
+		path := filepath.Join(dir, filename(info))
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		write(f, info)
+		if err = f.Close(); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// conoha, conoha-list など
+func docName(info *CommandInfo) string {
+	if info == conohaInfo {
+		return "conoha"
+	}
+	return "conoha-" + info.Name
+}
+
+func manFileName(info *CommandInfo) string {
+	return docName(info) + ".1"
+}
+
+func markdownFileName(info *CommandInfo) string {
+	return docName(info) + ".md"
+}
+
+// manページ(roff)を出力する
+func writeMan(w io.Writer, info *CommandInfo) {
+	name := docName(info)
+
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"conoha-vps %s\" \"ConoHa VPS CLI\"\n", strings.ToUpper(roffEscape(name)), lib.Version)
+
+	fmt.Fprintf(w, ".SH %s\n", lib.T("NAME"))
+	fmt.Fprintf(w, "%s \\- %s\n", roffEscape(name), roffEscape(lib.T(info.Summary)))
+
+	fmt.Fprintf(w, ".SH %s\n", lib.T("SYNOPSIS"))
+	fmt.Fprintf(w, ".B %s\n", roffEscape(commandName(info)))
+	if info.Synopsis != "" {
+		fmt.Fprintln(w, roffEscape(info.Synopsis))
+	}
+
+	fmt.Fprintf(w, ".SH %s\n", lib.T("DESCRIPTION"))
+	writeRoffLines(w, translateLines(info.Description))
+
+	if len(info.Args) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", lib.T("ARGUMENTS"))
+		for _, arg := range info.Args {
+			fmt.Fprintf(w, ".TP\n.B %s\n", roffEscape(arg.Name))
+			writeRoffLines(w, translateLines(arg.Description))
+		}
+	}
+
+	title := "OPTIONS"
+	if info == conohaInfo {
+		fmt.Fprintf(w, ".SH %s\n", lib.T("COMMANDS"))
+		for _, c := range visibleCommands() {
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR(1)\n", roffEscape(docName(c)))
+			fmt.Fprintln(w, roffEscape(lib.T(c.Summary)))
+		}
+		title = "GLOBAL OPTIONS"
+	}
+
+	if len(info.Flags) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", lib.T(title))
+		for _, f := range info.Flags {
+			fmt.Fprintf(w, ".TP\n%s\n", roffFlag(f))
+			writeRoffLines(w, flagDescription(f))
+		}
+	}
+
+	for _, section := range info.Sections {
+		fmt.Fprintf(w, ".SH %s\n", lib.T(section.Title))
+		for _, item := range section.Items {
+			switch {
+			case item.Term != "":
+				fmt.Fprintf(w, ".TP\n.B %s\n", roffEscape(item.Term))
+				fmt.Fprintln(w, roffEscape(lib.T(item.Text)))
+			default:
+				if item.Text != "" {
+					fmt.Fprintf(w, ".PP\n%s\n", roffEscape(lib.T(item.Text)))
+				}
+				fmt.Fprintln(w, ".RS 4\n.nf")
+				for _, line := range strings.Split(item.Command, "\n") {
+					fmt.Fprintln(w, roffEscape(line))
+				}
+				fmt.Fprintln(w, ".fi\n.RE")
+			}
+		}
+	}
+
+	fmt.Fprintf(w, ".SH %s\n", lib.T("SEE ALSO"))
+	if info == conohaInfo {
+		refs := []string{}
+		for _, c := range visibleCommands() {
+			refs = append(refs, fmt.Sprintf("\\fB%s\\fR(1)", roffEscape(docName(c))))
+		}
+		fmt.Fprintln(w, strings.Join(refs, ", "))
+	} else {
+		fmt.Fprintln(w, "\\fBconoha\\fR(1)")
+	}
+}
+
+// 説明の行は改行を保って出力する
+func writeRoffLines(w io.Writer, lines []string) {
+	for i, line := range lines {
+		if i > 0 {
+			fmt.Fprintln(w, ".br")
+		}
+		fmt.Fprintln(w, roffEscape(line))
+	}
+}
+
+// 「\fB\-o\fR, \fB\-\-output\fR」
+func roffFlag(f *FlagInfo) string {
+	names := []string{}
+	if f.Short != "" {
+		names = append(names, "\\fB"+roffEscape("-"+f.Short)+"\\fR")
+	}
+	if f.Name != "" {
+		names = append(names, "\\fB"+roffEscape("--"+f.Name)+"\\fR")
+	}
+	return strings.Join(names, ", ")
+}
+
+// roffの特殊文字をエスケープする
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+
+	// 行頭の . と ' はリクエストとみなされる
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// Markdownを出力する
+func writeMarkdown(w io.Writer, info *CommandInfo) {
+	fmt.Fprintf(w, "# %s\n\n", commandName(info))
+	fmt.Fprintf(w, "%s\n\n", lib.T(info.Summary))
+
+	fmt.Fprintf(w, "## %s\n\n", lib.T("SYNOPSIS"))
+	fmt.Fprintf(w, "```\n%s\n```\n\n", commandSynopsis(info))
+
+	fmt.Fprintf(w, "## %s\n\n", lib.T("DESCRIPTION"))
+	fmt.Fprintf(w, "%s\n\n", strings.Join(translateLines(info.Description), "  \n"))
+
+	if len(info.Args) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", lib.T("ARGUMENTS"))
+		for _, arg := range info.Args {
+			fmt.Fprintf(w, "- `%s`  \n  %s\n", arg.Name, strings.Join(translateLines(arg.Description), "  \n  "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	title := "OPTIONS"
+	if info == conohaInfo {
+		fmt.Fprintf(w, "## %s\n\n", lib.T("COMMANDS"))
+		for _, c := range visibleCommands() {
+			name := fmt.Sprintf("[%s](%s)", c.Name, markdownFileName(c))
+			if len(c.Aliases) > 0 {
+				name += " (" + strings.Join(c.Aliases, ", ") + ")"
+			}
+			fmt.Fprintf(w, "- %s  \n  %s\n", name, lib.T(c.Summary))
+		}
+		fmt.Fprintln(w)
+		title = "GLOBAL OPTIONS"
+	}
+
+	if len(info.Flags) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", lib.T(title))
+		for _, f := range info.Flags {
+			names := []string{}
+			if f.Short != "" {
+				names = append(names, "`-"+f.Short+"`")
+			}
+			if f.Name != "" {
+				names = append(names, "`--"+f.Name+"`")
+			}
+			lines := flagDescription(f)
+			if f.Example != "" {
+				// 例はコードとして表示する
+				lines[1] = lib.T("(e.g. %s)", "`"+f.Example+"`")
+			}
+			fmt.Fprintf(w, "- %s  \n  %s\n", strings.Join(names, ", "), strings.Join(lines, "  \n  "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, section := range info.Sections {
+		fmt.Fprintf(w, "## %s\n\n", lib.T(section.Title))
+		for _, item := range section.Items {
+			switch {
+			case item.Term != "":
+				fmt.Fprintf(w, "- `%s` %s\n", item.Term, lib.T(item.Text))
+			default:
+				if item.Text != "" {
+					fmt.Fprintf(w, "%s\n\n", lib.T(item.Text))
+				}
+				fmt.Fprintf(w, "```\n%s\n```\n\n", item.Command)
+			}
+		}
+		if len(section.Items) > 0 && section.Items[len(section.Items)-1].Term != "" {
+			fmt.Fprintln(w)
+		}
+	}
+
+	if info != conohaInfo {
+		fmt.Fprintf(w, "## %s\n\n[conoha](%s)\n", lib.T("SEE ALSO"), markdownFileName(conohaInfo))
+	}
+}
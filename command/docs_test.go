@@ -0,0 +1,153 @@
+package command
+
+import (
+	"bytes"
+	"github.com/mattn/go-runewidth"
+	flag "github.com/ogier/pflag"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 使い方に表示する文字列
+func usageTexts(info *CommandInfo) []string {
+	texts := []string{info.Summary, info.Description}
+	for _, arg := range info.Args {
+		texts = append(texts, arg.Description)
+	}
+	for _, f := range info.Flags {
+		texts = append(texts, f.Description)
+	}
+	for _, section := range info.Sections {
+		texts = append(texts, section.Title)
+		for _, item := range section.Items {
+			texts = append(texts, item.Text)
+		}
+	}
+	return texts
+}
+
+// 使い方の翻訳が漏れていないこと
+func TestUsageJa(t *testing.T) {
+	for _, info := range append([]*CommandInfo{conohaInfo}, visibleCommands()...) {
+		for _, text := range usageTexts(info) {
+			_, ok := usagesJa[text]
+			if _, msg := messagesJa[text]; text != "" && !ok && !msg {
+				t.Errorf("%s: no translation. [%s]", info.Name, text)
+			}
+		}
+	}
+}
+
+func TestWriteUsage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeUsage(buf, LookupCommand("stat"))
+
+	want := []string{
+		"Usage: conoha stat <VPS-ID> [OPTIONS]",
+		"    -6, --include-ipv6  Include IPv6 informations in output.",
+		"                        (e.g. '{{.IPv4}} {{join .IPv6 \",\"}}')",
+		"        --refresh       Ignore the cache and fetch the latest information.",
+	}
+	for _, line := range want {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("usage should contain [%s]\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestWriteTermsWidth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeTerms(buf, [][]string{{"一覧", "list"}, {"詳細情報", "stat"}, {"-h", "help"}})
+
+	// 全角文字を含む項目でも、説明は最も長い項目の表示幅+2の位置から始まること
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		i := strings.LastIndex(line, " ") + 1
+		if w := runewidth.StringWidth(line[:i]); w != len(usageIndent)+runewidth.StringWidth("詳細情報")+2 {
+			t.Errorf("terms are not aligned.\n%s", buf.String())
+		}
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeMan(buf, LookupCommand("power"))
+
+	want := []string{
+		".TH CONOHA\\-POWER 1 ",
+		"conoha\\-power \\- Send power\\-command to VPS.",
+		".TP\n\\fB\\-c\\fR, \\fB\\-\\-command\\fR\nPower command. It should be one of following.\n.br\n",
+		".SH SEE ALSO\n\\fBconoha\\fR(1)\n",
+	}
+	for _, s := range want {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("man page should contain [%s]\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestRoffEscape(t *testing.T) {
+	tests := map[string]string{
+		"--help":       `\-\-help`,
+		`{{.Id}}\t`:    `{{.Id}}\et`,
+		".conoha-vps":  `\&.conoha\-vps`,
+		"'table' json": `\&'table' json`,
+	}
+	for s, want := range tests {
+		if got := roffEscape(s); got != want {
+			t.Errorf("%s: got %s, want %s", s, got, want)
+		}
+	}
+}
+
+// registryのオプション(FlagInfo)と、各コマンドが実際にパースするオプションが一致すること
+func TestCommandFlags(t *testing.T) {
+	defer setupHome(t, "")()
+	defer func() { flagSetCreated = nil }()
+
+	argv := os.Args
+	defer func() { os.Args = argv }()
+
+	for _, info := range Commands() {
+		// sshは未定義のオプションをsshに渡すため、自前でパースしている
+		if info.Name == "ssh" {
+			continue
+		}
+
+		var fs *flag.FlagSet
+		flagSetCreated = func(f *flag.FlagSet) { fs = f }
+
+		os.Args = []string{"conoha", info.Name, "--help"}
+		c := info.New()
+		captureStdout(c.parseFlag)
+		if fs == nil {
+			// オプションのないコマンドはFlagSetを作らなくてもよい
+			if len(info.Flags) > 0 {
+				t.Errorf("%s: FlagSet is not created.", info.Name)
+			}
+			continue
+		}
+
+		defined := map[string]bool{}
+		for _, f := range info.Flags {
+			name := f.Name
+			if name == "" {
+				name = f.Short
+			}
+			defined[name] = true
+
+			pf := fs.Lookup(name)
+			if pf == nil {
+				t.Errorf("%s: --%s is not parsed.", info.Name, name)
+			} else if pf.Shorthand != f.Short {
+				t.Errorf("%s: shorthand of --%s is %q in registry, %q in FlagSet.", info.Name, name, f.Short, pf.Shorthand)
+			}
+		}
+
+		fs.VisitAll(func(pf *flag.Flag) {
+			if !defined[pf.Name] {
+				t.Errorf("%s: --%s is not in registry.", info.Name, pf.Name)
+			}
+		})
+	}
+}
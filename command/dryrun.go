@@ -0,0 +1,87 @@
+package command
+
+// ドライラン(--dry-run)で送信しなかったリクエストを表示する
+
+import (
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io"
+	"os"
+	"sort"
+)
+
+// この長さを超える値(__VIEWSTATEなど)は長さだけを表示する
+const dryRunMaxValueLength = 64
+
+// 送信しなかったリクエスト
+type dryRunRequest struct {
+	Method string            `json:"method" yaml:"method"`
+	Url    string            `json:"url" yaml:"url"`
+	Values map[string]string `json:"values" yaml:"values"`
+}
+
+// エラーがドライランで止めたものであれば、送信する内容を標準出力に表示してnilを返す
+// それ以外のエラーはそのまま返す
+func showDryRun(err error) error {
+	e, ok := err.(*cpanel.DryRunError)
+	if !ok {
+		return err
+	}
+	return writeDryRun(os.Stdout, GetGlobalOptions().Output, e)
+}
+
+func writeDryRun(w io.Writer, format string, e *cpanel.DryRunError) error {
+	req := &dryRunRequest{
+		Method: e.Method,
+		Url:    e.Url,
+		Values: dryRunValues(e),
+	}
+
+	keys := []string{}
+	for key := range req.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if format != lib.OutputTable {
+		table := &lib.Table{Header: []string{"Method", "Url", "Name", "Value"}}
+		for _, key := range keys {
+			table.Rows = append(table.Rows, []string{req.Method, req.Url, key, req.Values[key]})
+		}
+		return lib.Render(w, format, req, table)
+	}
+
+	fmt.Fprintln(w, lib.T("Dry run: the following request was not sent."))
+	fmt.Fprintf(w, "%s %s\n", req.Method, req.Url)
+	for _, key := range keys {
+		fmt.Fprintf(w, "    %s  %s\n", key, req.Values[key])
+	}
+	return nil
+}
+
+// VPSを変更するリクエストを送信した後にキャッシュを削除する
+// ドライランではリクエストを送信しないので削除しない
+func (c *Command) clearCache() {
+	if c.browser.DryRun {
+		return
+	}
+	lib.GetCacheInstance().Clear()
+}
+
+// 送信するパラメータ
+// パスワードなどは伏せ字にして、長い値は長さだけにする
+func dryRunValues(e *cpanel.DryRunError) map[string]string {
+	values := map[string]string{}
+	for key, v := range e.Values {
+		value := ""
+		if len(v) > 0 {
+			value = v[0]
+		}
+		if len(value) > dryRunMaxValueLength {
+			value = lib.T("(%d bytes)", len(value))
+		}
+		values[key] = value
+	}
+	return lib.RedactParams(values)
+}
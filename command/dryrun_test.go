@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bytes"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type testPasswordRequest struct{}
+
+func (r *testPasswordRequest) NewRequest(values url.Values) (*http.Request, error) {
+	values = url.Values{}
+	values.Add("txtRootPassword", "secret-password")
+	values.Add("__VIEWSTATE", strings.Repeat("a", 100))
+	values.Add("btnExecute", "決定")
+	return http.NewRequest("POST", "https://cp.conoha.jp/Service/VPS/Add/Confirm.aspx", strings.NewReader(values.Encode()))
+}
+
+func (r *testPasswordRequest) Irreversible() {}
+
+func TestDryRun(t *testing.T) {
+	browser := cpanel.NewBrowser().Fork()
+	browser.DryRun = true
+
+	// 取り消せない操作は送信せずに止める
+	browser.AddAction(&cpanel.Action{
+		Request: &labelChangeRequest{vmId: "f648a6646b7e7d91", label: "web-01"},
+		Result:  &labelChangeResult{},
+	})
+	err := browser.Run()
+	e, ok := err.(*cpanel.DryRunError)
+	if !ok {
+		t.Fatalf("should be DryRunError. [%v]", err)
+	}
+	if e.Method != "POST" || e.Url != "https://cp.conoha.jp/Service/ChangeLabel.aspx" || e.Values.Get("eid") != "f648a6646b7e7d91" || e.Values.Get("label") != "web-01" {
+		t.Errorf("got %+v", e)
+	}
+
+	browser.AddAction(&cpanel.Action{
+		Request: &vpsPowerRequest{vmId: "f648a6646b7e7d91", command: REBOOT},
+		Result:  &vpsPowerResult{},
+	})
+	err = browser.Run()
+	if e, ok := err.(*cpanel.DryRunError); !ok || e.Method != "GET" || e.Values.Get("command") != REBOOT {
+		t.Errorf("got %+v", err)
+	}
+
+	// パスワードは伏せ字にして、長い値は長さだけを表示する
+	browser.AddAction(&cpanel.Action{Request: &testPasswordRequest{}, Result: &addSubmitResult{}})
+	buf := &bytes.Buffer{}
+	if err = writeDryRun(buf, "table", browser.Run().(*cpanel.DryRunError)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"POST https://cp.conoha.jp/Service/VPS/Add/Confirm.aspx\n",
+		"    __VIEWSTATE  (100 bytes)\n",
+		"    btnExecute  決定\n",
+		"    txtRootPassword  ********\n",
+	}
+	for _, s := range want {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output should contain [%s]\n%s", s, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "secret-password") {
+		t.Errorf("password should be masked.\n%s", buf.String())
+	}
+}
+
+func TestClearCacheDryRun(t *testing.T) {
+	defer setupHome(t, "")()
+
+	cache := lib.GetCacheInstance()
+	cache.Configure(&lib.Config{Account: "C1"})
+	defer cache.Configure(&lib.Config{})
+	cache.Set("list", []string{"f648a6646b7e7d91"})
+
+	var servers []string
+	cmd := &Command{browser: &cpanel.Browser{DryRun: true}}
+	cmd.clearCache()
+	if !cache.Get("list", &servers) {
+		t.Errorf("cache should not be cleared in dry run.")
+	}
+
+	cmd.browser.DryRun = false
+	cmd.clearCache()
+	if cache.Get("list", &servers) {
+		t.Errorf("cache should be cleared.")
+	}
+}
@@ -0,0 +1,104 @@
+package command
+
+// 全てのコマンドで使えるオプション
+// コマンドのFlagSetは未定義のオプションをエラーにするので、mainでコマンドを実行する前に取り除いておく
+
+import (
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+)
+
+type GlobalOptions struct {
+	// メッセージの言語(--lang)
+	Lang string
+
+	// エラーのフォーマット(--error-format)。"text"か"json"
+	ErrorFormat string
+
+	// ログの設定(--log-level --log-format --log-file)
+	Log lib.LogOptions
+
+	// 使用するプロファイル(--profile、環境変数CONOHA_PROFILE)
+	Profile string
+
+	// 出力フォーマットのデフォルト(--output)。-o を持つコマンドで使う
+	Output string
+
+	// デバッグログを出力する(--debug)。--log-level debug と同じ
+	Debug bool
+
+	// 確認のプロンプトに全てyesと答える(--yes)
+	Yes bool
+
+	// 取り消せない操作のリクエストを送信せずに表示する(--dry-run)
+	DryRun bool
+}
+
+var globalOptions *GlobalOptions
+
+func GetGlobalOptions() *GlobalOptions {
+	if globalOptions == nil {
+		globalOptions = &GlobalOptions{
+			ErrorFormat: "text",
+			Output:      lib.OutputTable,
+		}
+	}
+	return globalOptions
+}
+
+// argsから全体のオプションを取り除いてGlobalOptionsにセットし、残りの引数を返す
+// ログの設定はconfigの値をデフォルトにする
+// エラーの場合もErrorFormatはセットされているので、エラーの出力に使える
+func ParseGlobalFlags(args []string, config *lib.Config) ([]string, error) {
+	var err error
+	opts := GetGlobalOptions()
+
+	// エラーを正しいフォーマットで出力できるように最初に処理する
+	opts.ErrorFormat, args = lib.ExtractFlag(args, "error-format", opts.ErrorFormat)
+	if opts.ErrorFormat != "text" && opts.ErrorFormat != "json" {
+		format := opts.ErrorFormat
+		opts.ErrorFormat = "text"
+		return args, &UsageError{Err: errors.New(lib.T(`Undefined error format "%s". It should be "text" or "json".`, format))}
+	}
+
+	// --lang が指定されていない場合は環境変数LANGなどから決定する
+	opts.Lang, args = lib.ExtractFlag(args, "lang", lib.DetectLang())
+	if err = lib.SetLang(opts.Lang); err != nil {
+		return args, &UsageError{Err: err}
+	}
+
+	opts.Log.Level, args = lib.ExtractFlag(args, "log-level", config.LogLevel)
+	opts.Log.Format, args = lib.ExtractFlag(args, "log-format", config.LogFormat)
+	opts.Log.File, args = lib.ExtractFlag(args, "log-file", config.LogFile)
+	opts.Profile, args = lib.ExtractFlag(args, "profile", os.Getenv("CONOHA_PROFILE"))
+	opts.Output, args = lib.ExtractFlag(args, "output", opts.Output)
+
+	if opts.Debug, args, err = lib.ExtractBoolFlag(args, "debug"); err != nil {
+		return args, &UsageError{Err: err}
+	}
+	if opts.Yes, args, err = lib.ExtractBoolFlag(args, "yes"); err != nil {
+		return args, &UsageError{Err: err}
+	}
+	if opts.DryRun, args, err = lib.ExtractBoolFlag(args, "dry-run"); err != nil {
+		return args, &UsageError{Err: err}
+	}
+
+	if opts.Debug {
+		opts.Log.Level = "debug"
+	}
+	if err = lib.ConfigureLog(&opts.Log); err != nil {
+		return args, &UsageError{Err: err}
+	}
+
+	if err = lib.ValidateOutputFormat(opts.Output); err != nil {
+		return args, &UsageError{Err: err}
+	}
+
+	// --profile、CONOHA_PROFILEのどちらも指定されていない場合は、conoha profile use で選択したプロファイルを使う
+	if opts.Profile != "" && !config.HasProfile(opts.Profile) {
+		return args, &NotFoundError{lib.T(`Profile "%s" is not found.`, opts.Profile)}
+	}
+
+	return args, nil
+}
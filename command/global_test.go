@@ -0,0 +1,92 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	defer func() { globalOptions = nil }()
+	globalOptions = nil
+
+	args := []string{"conoha", "--yes", "remove", "--lang", "en", "ID", "--output=json", "--debug", "--dry-run", "--", "--yes"}
+	rest, err := ParseGlobalFlags(args, &lib.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"conoha", "remove", "ID", "--", "--yes"}
+	if !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+
+	opts := GetGlobalOptions()
+	if !opts.Yes || !opts.Debug || !opts.DryRun || opts.Output != "json" || opts.Log.Level != "debug" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
+
+func TestParseGlobalFlagsError(t *testing.T) {
+	defer func() { globalOptions = nil }()
+
+	tests := [][]string{
+		{"conoha", "list", "--output", "xml"},
+		{"conoha", "list", "--error-format", "xml"},
+		{"conoha", "list", "--yes=maybe"},
+	}
+	for _, args := range tests {
+		globalOptions = nil
+		_, err := ParseGlobalFlags(args, &lib.Config{})
+		if ExitCodeOf(err) != ExitCodeUsage {
+			t.Errorf("%v: exit code = %d, want %d", args, ExitCodeOf(err), ExitCodeUsage)
+		}
+	}
+
+	// エラーのフォーマットが正しくない場合はtextで出力する
+	if GetGlobalOptions().ErrorFormat != "text" {
+		t.Errorf("ErrorFormat = %s, want text", GetGlobalOptions().ErrorFormat)
+	}
+}
+
+func TestParseGlobalFlagsProfile(t *testing.T) {
+	defer func() { globalOptions = nil }()
+	defer os.Setenv("CONOHA_PROFILE", os.Getenv("CONOHA_PROFILE"))
+
+	config := &lib.Config{}
+	config.SetProfile("staging", &lib.Profile{Account: "C2"})
+
+	// --profile が指定されていない場合は環境変数を使う
+	os.Setenv("CONOHA_PROFILE", "staging")
+	globalOptions = nil
+	if _, err := ParseGlobalFlags([]string{"conoha", "list"}, config); err != nil || GetGlobalOptions().Profile != "staging" {
+		t.Errorf("got %q [%v]", GetGlobalOptions().Profile, err)
+	}
+
+	globalOptions = nil
+	if _, err := ParseGlobalFlags([]string{"conoha", "list", "--profile", "production"}, config); ExitCodeOf(err) != ExitCodeNotFound {
+		t.Errorf("undefined profile should be not found. [%v]", err)
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	tests := map[string]string{
+		"list":   "list",
+		"ls":     "list",
+		"rm":     "remove",
+		"show":   "stat",
+		"ssh":    "ssh",
+		"delete": "",
+	}
+	for name, want := range tests {
+		info := LookupCommand(name)
+		if want == "" {
+			if info != nil {
+				t.Errorf("%s: got %s, want nil", name, info.Name)
+			}
+		} else if info == nil || info.Name != want {
+			t.Errorf("%s: got %v, want %s", name, info, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package command
+
+// コマンドの使い方を表示する
+// conoha help COMMAND は conoha COMMAND -h と同じ
+
+import (
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+)
+
+type Help struct {
+	// 使い方を表示するコマンド。指定されていない場合は全体の使い方を表示する
+	target *CommandInfo
+
+	*Command
+}
+
+func NewHelp() *Help {
+	return &Help{
+		Command: NewCommand(),
+	}
+}
+
+func (cmd *Help) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if name := fs.Arg(1); name != "" {
+		cmd.target = LookupCommand(name)
+		if cmd.target == nil {
+			cmd.target = LookupPlugin(name)
+		}
+		if cmd.target == nil {
+			return errors.New(lib.T(`Undefined command "%s".`, name))
+		}
+	}
+	return nil
+}
+
+func (cmd *Help) Usage() {
+	printUsage("help")
+}
+
+func (cmd *Help) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	if cmd.target == nil {
+		NewNocommand().Usage()
+	} else {
+		cmd.target.New().Usage()
+	}
+	return nil
+}
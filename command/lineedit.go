@@ -0,0 +1,243 @@
+package command
+
+// conoha shellの行編集
+// 端末をrawモードにして1行読む。履歴とタブ補完に対応する
+
+import (
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	runewidth "github.com/mattn/go-runewidth"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+type lineEditor struct {
+	prompt string
+
+	// 入力中の行とカーソル位置
+	buf []rune
+	pos int
+
+	// 入力した行の履歴。histPosがlen(history)の場合は入力中の行を表す
+	history []string
+	histPos int
+	editing []rune
+
+	// 入力中の単語の補完候補を返す関数(completeWordsと同じ形式)
+	complete func(words []string) []string
+
+	// 補完候補が複数ある場合に一覧を表示する
+	listing []string
+}
+
+func newLineEditor(prompt string, complete func(words []string) []string) *lineEditor {
+	return &lineEditor{
+		prompt:   prompt,
+		complete: complete,
+	}
+}
+
+// 1行読む。空の行でCtrl-Dを押した場合はio.EOFを返す
+func (e *lineEditor) readLine() (string, error) {
+	restore, err := lib.MakeRaw(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.buf = []rune{}
+	e.pos = 0
+	e.histPos = len(e.history)
+
+	for {
+		e.draw()
+
+		keys, err := lib.ReadKeys(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range keys {
+			done, err := e.handleKey(key)
+			if err != nil {
+				fmt.Print("\r\n")
+				return "", err
+			}
+			if done {
+				fmt.Print("\r\n")
+				line := string(e.buf)
+				if strings.TrimSpace(line) != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != line) {
+					e.history = append(e.history, line)
+				}
+				return line, nil
+			}
+
+			// 補完候補を一覧表示して、入力中の行を表示し直す
+			if len(e.listing) > 0 {
+				e.draw()
+				fmt.Print("\r\n" + strings.Join(e.listing, "\r\n") + "\r\n")
+				e.listing = nil
+			}
+		}
+	}
+}
+
+// 入力中の行を表示し直す
+func (e *lineEditor) draw() {
+	fmt.Print("\r\x1b[K" + e.prompt + string(e.buf))
+	if n := runewidth.StringWidth(string(e.buf[e.pos:])); n > 0 {
+		fmt.Printf("\x1b[%dD", n)
+	}
+}
+
+// キー入力を処理する。行の入力が終わった場合はtrueを返す
+func (e *lineEditor) handleKey(key lib.Key) (bool, error) {
+	switch key.Code {
+	case lib.KeyEnter:
+		return true, nil
+	case lib.KeyCtrlC:
+		// 入力中の行を捨てる
+		e.buf = []rune{}
+		e.pos = 0
+		return true, nil
+	case lib.KeyCtrlD:
+		if len(e.buf) == 0 {
+			return false, io.EOF
+		}
+		e.delete(e.pos, e.pos+1)
+	case lib.KeyLeft:
+		if e.pos > 0 {
+			e.pos--
+		}
+	case lib.KeyRight:
+		if e.pos < len(e.buf) {
+			e.pos++
+		}
+	case lib.KeyHome, lib.KeyCtrlA:
+		e.pos = 0
+	case lib.KeyEnd, lib.KeyCtrlE:
+		e.pos = len(e.buf)
+	case lib.KeyBackspace:
+		if e.pos > 0 {
+			e.delete(e.pos-1, e.pos)
+		}
+	case lib.KeyDelete:
+		e.delete(e.pos, e.pos+1)
+	case lib.KeyCtrlU:
+		e.delete(0, e.pos)
+	case lib.KeyCtrlK:
+		e.delete(e.pos, len(e.buf))
+	case lib.KeyCtrlW:
+		// カーソルの前の単語を削除する
+		i := e.pos
+		for i > 0 && unicode.IsSpace(e.buf[i-1]) {
+			i--
+		}
+		for i > 0 && !unicode.IsSpace(e.buf[i-1]) {
+			i--
+		}
+		e.delete(i, e.pos)
+	case lib.KeyUp:
+		e.moveHistory(-1)
+	case lib.KeyDown:
+		e.moveHistory(1)
+	case lib.KeyTab:
+		e.completeWord()
+	case lib.KeyNone:
+		if unicode.IsPrint(key.Rune) {
+			e.insert(string(key.Rune))
+		}
+	}
+	return false, nil
+}
+
+// カーソル位置に文字列を挿入する
+func (e *lineEditor) insert(s string) {
+	r := []rune(s)
+	buf := append([]rune{}, e.buf[:e.pos]...)
+	buf = append(buf, r...)
+	e.buf = append(buf, e.buf[e.pos:]...)
+	e.pos += len(r)
+}
+
+// [from, to)の文字を削除してカーソルをfromに移動する
+func (e *lineEditor) delete(from int, to int) {
+	if to > len(e.buf) {
+		to = len(e.buf)
+	}
+	if from >= to {
+		return
+	}
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	e.pos = from
+}
+
+// 履歴を辿る
+func (e *lineEditor) moveHistory(n int) {
+	i := e.histPos + n
+	if i < 0 || i > len(e.history) {
+		return
+	}
+
+	// 履歴を辿り始める時に入力中の行を覚えておく
+	if e.histPos == len(e.history) {
+		e.editing = e.buf
+	}
+
+	e.histPos = i
+	if i == len(e.history) {
+		e.buf = e.editing
+	} else {
+		e.buf = []rune(e.history[i])
+	}
+	e.pos = len(e.buf)
+}
+
+// カーソルの前の単語を補完する
+// 候補が一つの場合はそれを挿入し、複数の場合は共通する部分まで挿入する
+// それ以上補完できない場合は候補を一覧表示する
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+
+	before := string(e.buf[:e.pos])
+	words := strings.Fields(before)
+	if len(words) == 0 || unicode.IsSpace(e.buf[e.pos-1]) {
+		words = append(words, "")
+	}
+	cur := words[len(words)-1]
+
+	candidates := e.complete(words)
+	if len(candidates) == 0 {
+		return
+	}
+
+	values := []string{}
+	for _, c := range candidates {
+		values = append(values, strings.SplitN(c, "\t", 2)[0])
+	}
+
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	if len(values) == 1 {
+		e.insert(strings.TrimPrefix(prefix, cur) + " ")
+	} else if len(prefix) > len(cur) && strings.HasPrefix(prefix, cur) {
+		e.insert(prefix[len(cur):])
+	} else {
+		for _, c := range candidates {
+			kv := strings.SplitN(c, "\t", 2)
+			if len(kv) == 2 {
+				e.listing = append(e.listing, lib.PadRight(kv[0], 20)+"  "+kv[1])
+			} else {
+				e.listing = append(e.listing, kv[0])
+			}
+		}
+	}
+}
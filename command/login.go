@@ -7,7 +7,6 @@ import (
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
 	"github.com/howeyc/gopass"
-	flag "github.com/ogier/pflag"
 	"net/http"
 	"net/url"
 	"os"
@@ -30,12 +29,11 @@ type Login struct {
 func (cmd *Login) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.StringVarP(&cmd.account, "account", "a", "", "ConoHa Account")
-	fs.StringVarP(&cmd.password, "password", "p", "", "ConoHa Password")
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.account, "account", "a", "", "")
+	fs.StringVarP(&cmd.password, "password", "p", "", "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
@@ -51,7 +49,7 @@ func (cmd *Login) parseFlag() error {
 
 		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける
 		if err := cmd.inputAccountInfo(); err != nil {
-			return errors.New("Not enough arguments.")
+			return errors.New(lib.T("Not enough arguments."))
 		}
 	}
 
@@ -59,17 +57,7 @@ func (cmd *Login) parseFlag() error {
 }
 
 func (cd *Login) Usage() {
-	fmt.Println(`Usage: conoha login [OPTIONS]
-
-DESCRIPTION
-    Authenticate an account.
-    If account or password not set, you can input interactively.
-
-OPTIONS
-    -a: --account:   ConoHa Account.
-    -p: --password:  Password.
-    -h: --help:      Show usage.  
-`)
+	printUsage("login")
 }
 
 func (cmd *Login) Run() error {
@@ -77,7 +65,7 @@ func (cmd *Login) Run() error {
 
 	var err error
 	if err = cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
 	}
 
 	cmd.config.Account = cmd.account
@@ -88,11 +76,11 @@ func (cmd *Login) Run() error {
 		return err
 	}
 
-	if loggedIn {
-		log.Infof("Login Successfully.")
-	} else {
-		log.Infof("Login failed. Enter correct ConoHa account ID and password.")
+	if !loggedIn {
+		return &AuthError{lib.T("Login failed. Enter correct ConoHa account ID and password.")}
 	}
+
+	log.Info(lib.T("Login Successfully."))
 	return nil
 }
 
@@ -138,14 +126,14 @@ func (cmd *Login) inputAccountInfo() error {
 	var n int
 	var err error
 
-	println("Please input ConoHa account.")
-	print("ConoHa Account: ")
+	println(lib.T("Please input ConoHa account."))
+	print(lib.T("ConoHa Account: "))
 	n, err = fmt.Scanf("%s", &cmd.account)
 	if n != 1 || err != nil {
 		return err
 	}
 
-	print("Password: ")
+	print(lib.T("Password: "))
 	cmd.password = string(gopass.GetPasswd())
 
 	return nil
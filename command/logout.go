@@ -1,9 +1,7 @@
 package command
 
 import (
-	"fmt"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"os"
 )
 
@@ -20,10 +18,9 @@ func NewLogout() *Logout {
 func (cmd *Logout) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
+	fs.BoolVarP(&help, "help", "h", false, "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
@@ -39,19 +36,20 @@ func (cmd *Logout) parseFlag() error {
 }
 
 func (cmd *Logout) Usage() {
-	fmt.Println(`Usage: conoha logout [OPTIONS ...]
-
-DESCRIPTION
-    Remove an authenticate file(~/.conoha-vps).
-
-OPTIONS
-    -h: --help:     Show usage.      
-`)
+	printUsage("logout")
 }
 
 func (cmd *Logout) Run() error {
 	if err := cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
+	}
+
+	lib.GetCacheInstance().Clear()
+
+	// 他にプロファイルがある場合は、使用中のプロファイルのアカウントとセッションだけを削除する
+	if len(cmd.config.Profiles) > 0 {
+		cmd.config.SetProfile(cmd.config.Profile(), &lib.Profile{})
+		return cmd.config.Write()
 	}
 
 	cmd.config.Remove()
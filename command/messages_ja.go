@@ -0,0 +1,403 @@
+package command
+
+// 日本語のメッセージカタログ
+// キーは英語のメッセージ
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+)
+
+func init() {
+	lib.RegisterMessages(lib.LangJa, messagesJa)
+	lib.RegisterMessages(lib.LangJa, usagesJa)
+}
+
+var messagesJa = map[string]string{
+	// エラー
+	"VPS not found.":                       "VPSが見つかりません。",
+	"VPS not found(id=%s).":                "VPSが見つかりません(id=%s)。",
+	"Session is timed out. Please log in.": "セッションがタイムアウトしました。ログインしてください。",
+	"Canceled.":                            "中止しました。",
+	"Not enough arguments.":                "引数が足りません。",
+	`Undefined command "%s".`:              `"%s"というコマンドはありません。`,
+	"Account is not set. Please log in.":   "アカウントが設定されていません。ログインしてください。",
+	`Invalid snapshot name "%s".`:          `スナップショット名"%s"は使えません。`,
+	`Snapshot "%s" not found.`:             `スナップショット"%s"が見つかりません。`,
+	`Snapshot "%s" is created by a newer version(format version %d).`: `スナップショット"%s"は新しいバージョンで作成されています(フォーマットのバージョン %d)。`,
+	`Undefined action "%s".`:     `"%s"というアクションはありません。`,
+	"SSH Key not found.":         "SSH鍵が見つかりません。",
+	"SSH key not found.":         "SSH鍵が見つかりません。",
+	"Invalid PlanType.":          "プランの種類が正しくありません。",
+	"Invalid Plan.":              "プランが正しくありません。",
+	"Invalid Template.":          "テンプレートイメージが正しくありません。",
+	"Root password is required.": "rootパスワードが必要です。",
+	`PlanType(-t) parameter should be "basic" or "windows".`: `プランの種類(-t)は"basic"か"windows"を指定してください。`,
+	"Plan(-p) is invalid.":                                     "プラン(-p)が正しくありません。",
+	"Template Image(-i) is invalid.":                           "テンプレートイメージ(-i)が正しくありません。",
+	"Undefined plan type.":                                     "未定義のプランの種類です。",
+	`Undefined field "%s".`:                                    `"%s"というフィールドはありません。`,
+	`Invalid filter "%s".`:                                     `条件"%s"が正しくありません。`,
+	`Invalid filter "%s". It should be like "status=Running".`: `条件"%s"が正しくありません。"status=Running"のように指定してください。`,
+	"Label is too long(should be 20 characters or less). ":     "ラベルが長すぎます(20文字以内で指定してください)。",
+	"Server returned the errror status code(%d).":              "サーバーがエラーを返しました(ステータスコード %d)。",
+	`Undefined operation "%s". It should be one of "add", "remove", "power" or "label".`: `"%s"という操作はありません。"add" "remove" "power" "label"のいずれかを指定してください。`,
+	`Invalid time "%s". It should be like "2015-01-01", "2015-01-01 12:00" or "24h".`:    `"%s"は正しい日時ではありません。"2015-01-01" "2015-01-01 12:00" "24h"のように指定してください。`,
+	`Undefined shell "%s". It should be "bash", "zsh" or "fish".`:                        `"%s"というシェルには対応していません。"bash" "zsh" "fish"のいずれかを指定してください。`,
+	`Profile "%s" already exists.`:                                                       `"%s"というプロファイルは既にあります。`,
+	`Profile "%s" is in use.`:                                                            `"%s"というプロファイルは使用中です。`,
+	`Invalid profile name "%s".`:                                                         `"%s"はプロファイル名に使えません。`,
+	`The default profile can not be removed. Use "conoha logout" instead.`:               `デフォルトのプロファイルは削除できません。代わりに"conoha logout"を使ってください。`,
+	`Profile "%s" is added.`:                                                             `プロファイル"%s"を追加しました。`,
+	`Profile "%s" is removed.`:                                                           `プロファイル"%s"を削除しました。`,
+	`Switched to profile "%s".`:                                                          `プロファイル"%s"に切り替えました。`,
+	`Profile "%s" is not found.`:                                                         `"%s"というプロファイルはありません。`,
+	`Invalid value "%s" for %s.`:                                                         `%[2]s の値"%[1]s"が正しくありません。`,
+	"Could not write the audit log: %s":                                                  "監査ログを書き込めませんでした: %s",
+	"--limit should be 0 or greater(0 means no limit).":                                  "--limit は0以上の数を指定してください(0の場合は制限しません)。",
+	"--parallel should be a positive number.":                                            "--parallel は正の数を指定してください。",
+	"Could not get the details of VPS(id=%s): %s":                                        "VPSの詳細を取得できませんでした(id=%s): %s",
+	"Could not get the details of %d VPS.":                                               "%d台のVPSの詳細を取得できませんでした。",
+	`Could not send "%s" command. VPS is already running.`:                               `"%s"コマンドを送信できません。VPSは既に稼働中です。`,
+	`Could not send "%s" command.  VPS might be offiline.`:                               `"%s"コマンドを送信できません。VPSが停止している可能性があります。`,
+	"Timed out after %s waiting for VPS(id=%s).":                                         "VPS(id=%[2]s)を%[1]s待ちましたがタイムアウトしました。",
+	` Last status is "%s".`:                                                              ` 最後の状態は"%s"です。`,
+	`VPS(id=%s) can not become "%s" from "%s".`:                                          `VPS(id=%[1]s)は"%[3]s"から"%[2]s"になることはできません。`,
+	`Undefined status "%s".`:                                                             `"%s"という状態はありません。`,
+	"Timeout and interval should be greater than zero.":                                  "タイムアウトと間隔は0より大きい値を指定してください。",
+	"Interval should be greater than zero.":                                              "間隔は0より大きい値を指定してください。",
+	"%s (retry after %s)":                                                                "%s (%s後に再試行します)",
+	`Undefined output format "%s".`:                                                      `"%s"という出力フォーマットはありません。`,
+	`Output format "%s" is not supported.`:                                               `出力フォーマット"%s"には対応していません。`,
+	`Undefined color mode "%s". It should be "auto", "always" or "never".`:               `"%s"は指定できません。"auto" "always" "never"のどれかを指定してください。`,
+	`Undefined error format "%s". It should be "text" or "json".`:                        `"%s"というエラーフォーマットはありません。"text"か"json"を指定してください。`,
+	`Undefined log level "%s".`:                                                          `"%s"というログレベルはありません。`,
+	`Undefined log format "%s". It should be "text", "simple" or "json".`:                `"%s"というログフォーマットはありません。"text" "simple" "json"のいずれかを指定してください。`,
+	`"%s" matches %d VPS. Specify one of the following.`:                                 `"%s"に一致するVPSが%d台あります。次のどれかを指定してください。`,
+	"Specify VPS-ID. VPS can not be selected because the terminal is not available.":     "VPS-IDを指定してください。端末が使えないためVPSを選択できません。",
+	"Already running in the shell.":                                                      "既にシェルで実行しています。",
+	"Unterminated quote or escape.":                                                      "クォートかエスケープが閉じられていません。",
+	"Too many arguments.":                                                                "引数が多すぎます。",
+	"No command is specified.":                                                           "コマンドが指定されていません。",
+	`Variable "%s" is not defined.`:                                                      `変数"%s"は定義されていません。`,
+	"line %d: %s":                                                                        "%d行目: %s",
+	"%d of %d lines failed.":                                                             "%[2]d行中%[1]d行が失敗しました。",
+	"This command requires a terminal.":                                                  "このコマンドは端末で実行してください。",
+	"The details of VPS are not loaded yet.":                                             "VPSの詳細をまだ取得していません。",
+	"Either --man or --markdown is required.":                                            "--man か --markdown を指定してください。",
+
+	// メッセージ
+	"Login Successfully.": "ログインしました。",
+	"Login failed. Enter correct ConoHa account ID and password.": "ログインに失敗しました。正しいConoHaのアカウントIDとパスワードを入力してください。",
+	`Snapshot "%s" is saved to "%s"(%d VPS).`:                     `スナップショット"%s"を"%s"に保存しました(VPS %d台)。`,
+	`Snapshot "%s" is removed.`:                                   `スナップショット"%s"を削除しました。`,
+	"ID=%s. Windows plan is not supported ssh connect.":           "ID=%s. WindowsプランにはSSHで接続できません。",
+	`Download is complete. A private key is stored in "%s".`:      `ダウンロードが完了しました。秘密鍵を"%s"に保存しました。`,
+	"Adding VPS is complete.":                                     "VPSを追加しました。",
+	"No differences.":                                             "差分はありません。",
+	`VPS Label was changed to "%s"`:                               `VPSのラベルを"%s"に変更しました。`,
+	`"%s" command was sent to VPS(id=%s).`:                        `"%s"コマンドをVPS(id=%s)に送信しました。`,
+	"Removing VPS is complete.":                                   "VPSを削除しました。",
+	"VPS(id=%s) is %s.":                                           "VPS(id=%s)は%sになりました。",
+	"not found":                                                   "見つかりません",
+	`Sending "%s" command...`:                                     `"%s"コマンドを送信しています...`,
+	"Copied %s to the clipboard.":                                 "%s をクリップボードにコピーしました。",
+	"Waiting for VPS(id=%s) to be %s... %s (%s elapsed)":          "VPS(id=%s)が%sになるのを待っています... %s (%s経過)",
+
+	// プロンプト
+	"Please input ConoHa account.": "ConoHaのアカウントを入力してください。",
+	"ConoHa Account: ":             "ConoHaアカウント: ",
+	"Password: ":                   "パスワード: ",
+	`Send "%s" command to VPS(Label=%s). Are you sure?`: `VPS(ラベル=%[2]s)に"%[1]s"コマンドを送信します。よろしいですか?`,
+	"Remove VPS[Label=%s]. Are you sure?":               "VPS[ラベル=%s]を削除します。よろしいですか?",
+
+	// サーバーの状態
+	"Running":   "稼働中",
+	"Offline":   "停止",
+	"No status": "取得中",
+	"Preparing": "サービス準備中",
+	"-":         "未取得",
+	"Unknown":   "不明",
+
+	// VPSの選択
+	"Select VPS":                "VPSを選択",
+	"Select VPS (Tab to check)": "VPSを選択 (Tabでチェック)",
+
+	// プラグイン
+	`Plugin "%s" exited with status %d.`: `プラグイン"%s"が終了コード%dで終了しました。`,
+
+	// ドライラン(--dry-run)
+	"Dry run: the following request was not sent.": "ドライラン: 次のリクエストは送信していません。",
+	"(%d bytes)": "(%dバイト)",
+
+	// バッチ(conoha batch)
+	"Summary:": "結果:",
+
+	// シェル(conoha shell)
+	`Type "help" to show the commands, "exit" to quit.`: `"help"でコマンドの一覧、"exit"で終了します。`,
+	"Exit the shell.": "シェルを終了します。",
+
+	// ダッシュボード(conoha ui)
+	"%d VPS":        "VPS %d台",
+	"Updated at %s": "%s 更新",
+	"Loading...":    "取得中...",
+	"New label: ":   "新しいラベル: ",
+	"Select":        "選択",
+	"Boot":          "起動",
+	"Reboot":        "再起動",
+	"Shutdown":      "シャットダウン",
+	"Stop":          "強制停止",
+	"SSH":           "SSH",
+	"Copy IP":       "IPをコピー",
+	"Update":        "更新",
+	"Quit":          "終了",
+
+	// コマンドの説明(conoha -h)
+	"COMMANDS":                                    "コマンド",
+	"Add VPS.":                                    "VPSを追加します。",
+	"Show the audit log of the operations.":       "操作の監査ログを表示します。",
+	"Output the shell completion script.":         "シェルの補完スクリプトを出力します。",
+	"Show differences between snapshots.":         "スナップショットの差分を表示します。",
+	"Show usage of the command.":                  "コマンドの使い方を表示します。",
+	"Change VPS label.":                           "VPSのラベルを変更します。",
+	"List VPS.":                                   "VPSの一覧を表示します。",
+	"Authenticate an account.":                    "ログインします。",
+	"Remove an authenticate file(~/.conoha-vps).": "認証ファイル(~/.conoha-vps)を削除します。",
+	"Manage account profiles.":                    "アカウントのプロファイルを管理します。",
+	"Send power-command to VPS.":                  "VPSに電源操作のコマンドを送信します。",
+	"Remove VPS.":                                 "VPSを削除します。",
+	"Save the details of all VPS.":                "全VPSの詳細を保存します。",
+	"Download and store SSH Private key.":         "SSHの秘密鍵をダウンロードして保存します。",
+	"Login to VPS via SSH.":                       "SSHでVPSにログインします。",
+	"Display VPS information.":                    "VPSの詳細を表示します。",
+	"Display version.":                            "バージョンを表示します。",
+	"Wait until VPS satisfies the condition.":     "VPSが指定した状態になるまで待ちます。",
+	"Watch VPS and print changes as JSON lines.":  "VPSを監視して変更をJSON Linesで表示します。",
+	"Run commands written in a file.":             "ファイルに書いたコマンドを実行します。",
+	"Run commands interactively.":                 "対話的にコマンドを実行します。",
+	"Show the dashboard of VPS in the terminal.":  "VPSのダッシュボードを端末に表示します。",
+	"Generate man pages and markdown reference.":  "manページとMarkdownのリファレンスを作成します。",
+
+	// 表の項目名
+	"VPS ID":              "VPS ID",
+	"Label":               "ラベル",
+	"Plan":                "プラン",
+	"Server Status":       "サーバー状態",
+	"Service Status":      "サービス状態",
+	"CreatedAt":           "作成日時",
+	"Account":             "アカウント",
+	"IPv4":                "IPv4",
+	"CPU":                 "CPU",
+	"Memory":              "メモリ",
+	"House":               "収容ホスト",
+	"Name":                "名前",
+	"VPS":                 "VPS",
+	"ServerStatus":        "サーバー状態",
+	"ServiceStatus":       "サービス状態",
+	"Service ID":          "サービスID",
+	"Created At":          "作成日時",
+	"Delete Date":         "削除予定日",
+	"Payment Span":        "支払いサイクル",
+	"Disk1":               "ディスク1",
+	"Disk2":               "ディスク2",
+	"IPv4 Address":        "IPv4アドレス",
+	"IPv4 Netmask":        "IPv4ネットマスク",
+	"IPv4 Gateway":        "IPv4ゲートウェイ",
+	"IPv4 DNS1":           "IPv4 DNS1",
+	"IPv4 DNS2":           "IPv4 DNS2",
+	"IPv6 Address":        "IPv6アドレス",
+	"IPv6 Gateway":        "IPv6ゲートウェイ",
+	"IPv6 DNS1":           "IPv6 DNS1",
+	"IPv6 DNS2":           "IPv6 DNS2",
+	"Host Server":         "収容ホスト",
+	"Common Server ID":    "共通サーバーID",
+	"Serial Console(SSH)": "シリアルコンソール(SSH)",
+	"ISO Upload(SFTP)":    "ISOアップロード(SFTP)",
+	"Time":                "日時",
+	"User":                "ユーザー",
+	"Operation":           "操作",
+	"Parameters":          "パラメータ",
+	"Confirmation":        "確認",
+	"Outcome":             "結果",
+}
+
+// 使い方(-h)、manページ、Markdownのリファレンス
+// キーはregistry.goの説明
+var usagesJa = map[string]string{
+	// 見出し
+	"Usage:":         "使い方:",
+	"NAME":           "名前",
+	"SYNOPSIS":       "書式",
+	"DESCRIPTION":    "説明",
+	"ARGUMENTS":      "引数",
+	"OPTIONS":        "オプション",
+	"GLOBAL OPTIONS": "全体のオプション",
+	"PLUGINS":        "プラグイン",
+	"EXAMPLE":        "例",
+	"EXIT STATUS":    "終了コード",
+	"OUTPUT":         "出力",
+	"SEE ALSO":       "関連項目",
+	"(e.g. %s)":      "(例 %s)",
+
+	// 共通
+	"Show usage.": "使い方を表示します。",
+	"Output format. It should be one of following.\n(\"table\" \"json\" \"yaml\" \"csv\" \"tsv\") Default is \"table\".":    "出力フォーマット。次のどれかを指定します。\n(\"table\" \"json\" \"yaml\" \"csv\" \"tsv\") デフォルトは\"table\"です。",
+	"Format the output using the Go template.\nThe functions \"join\" \"upper\" \"lower\" \"date\" \"json\" are available.": "Goのテンプレートで出力します。\n\"join\" \"upper\" \"lower\" \"date\" \"json\"の関数が使えます。",
+	"Ignore the cache and fetch the latest information.":                                                                    "キャッシュを使わずに最新の情報を取得します。",
+	"Do not read or write the cache.": "キャッシュを読み書きしません。",
+	"(Optional) VPS-ID. It may be confirmed by \"conoha list\".\nThe label, a unique prefix of VPS-ID, the service ID or an IP address is also accepted.\nIf not set, it will be selected interactively from VPS list.": "(省略可) VPS-ID。\"conoha list\"で確認できます。\nラベル、VPS-IDの前方一致(一意な場合)、サービスID、IPアドレスでも指定できます。\n指定しない場合は一覧から対話的に選択します。",
+	"SSH Key number. Default is 1.\nIf the number of keys is one, it will be ignored.": "SSH鍵の番号。デフォルトは1です。\n鍵が一つの場合は無視されます。",
+
+	// conoha
+	"A CLI-Tool for ConoHa VPS.": "ConoHa VPSのためのCLIツールです。",
+	"A CLI-Tool for ConoHa VPS.\nGlobal options can be placed anywhere in the command line.\nRun \"conoha help COMMAND\" to show usage of the command.": "ConoHa VPSのためのCLIツールです。\n全体のオプションはコマンドラインのどこに指定しても構いません。\n\"conoha help COMMAND\" でコマンドの使い方を表示します。",
+	"Language of messages. It should be \"en\" or \"ja\".\nIf not set, it is detected from LANG environment variable.":                                  "メッセージの言語。\"en\"か\"ja\"を指定します。\n指定しない場合は環境変数LANGから決定します。",
+	"Format of the error message written to stderr.\nIt should be \"text\" or \"json\". Default is \"text\".":                                           "標準エラー出力に出力するエラーのフォーマット。\n\"text\"か\"json\"を指定します。デフォルトは\"text\"です。",
+	"Log level. It should be \"debug\", \"info\", \"warning\" or \"error\".\nDefault is \"info\".":                                                      "ログレベル。\"debug\" \"info\" \"warning\" \"error\"のいずれかを指定します。\nデフォルトは\"info\"です。",
+	"Log format. It should be \"text\", \"simple\" or \"json\".\nDefault is \"text\".":                                                                  "ログのフォーマット。\"text\" \"simple\" \"json\"のいずれかを指定します。\nデフォルトは\"text\"です。",
+	"Also append log entries to the file.": "ログをファイルにも追記します。",
+	"Account profile to use.\nIf not set, CONOHA_PROFILE environment variable or the profile selected by \"conoha profile use\" is used.": "使用するアカウントのプロファイル。\n指定しない場合は環境変数CONOHA_PROFILEか、\"conoha profile use\"で選択したプロファイルを使います。",
+	"Default output format of the commands that have -o option.":                                                                          "-o オプションを持つコマンドの出力フォーマットのデフォルト。",
+	"Same as --log-level debug.": "--log-level debug と同じです。",
+	"Answer \"yes\" to all confirmations(same as -f of power and remove).":                                                        "全ての確認に\"yes\"と答えます(powerとremoveの -f と同じです)。",
+	"Do not send the request of add, remove, power and label.\nShow the parameters of the request instead(passwords are masked).": "add、remove、power、labelのリクエストを送信しません。\n代わりにリクエストのパラメータを表示します(パスワードは伏せ字になります)。",
+	"Success.":                              "成功",
+	"Error.":                                "エラー",
+	"Timeout.":                              "タイムアウト",
+	"Not found.":                            "見つからない",
+	"Usage error.":                          "引数が正しくない",
+	"Authentication failure.":               "認証の失敗",
+	"Unexpected HTML of the control panel.": "コントロールパネルのHTMLを解析できない",
+	"Declined the confirmation.":            "確認で中止した",
+
+	// add
+	"Add VPS to your account.": "VPSを追加します。",
+	"VPS Type. It should be \"basic\" or \"windows\".\nIf not set, it will be \"basic\".":                            "VPSの種類。\n\"basic\"か\"windows\"を指定します。指定しない場合は\"basic\"になります。",
+	"VPS Plan.\nIt allows only numeric(1=1G, 2=2G ... 16=16G).":                                                      "VPSのプラン。\n数字で指定します(1=1G, 2=2G ... 16=16G)。",
+	"Template image. It should be one of the following.\n(\"centos\" \"wordpress\" \"windows2012\" \"windows2008\")": "テンプレートイメージ。次のどれかを指定します。\n(\"centos\" \"wordpress\" \"windows2012\" \"windows2008\")",
+	"Root password.\nIf the VPS Type is \"basic\" only.":                                                             "rootパスワード。\nVPSの種類が\"basic\"の場合のみ指定します。",
+	"Standard Plan, 2vCPU, 1GB Memory and CentOS6.5.":                                                                "Standardプラン、2vCPU、メモリ1GB、CentOS6.5",
+	"Standard Plan, 4vCPU, 4GB Memory and CentOS6.5 + nginx + WordPress.":                                            "Standardプラン、4vCPU、メモリ4GB、CentOS6.5 + nginx + WordPress",
+	"Windows Plan, 8vCPU, 8GB Memory and Windows Server 2012 R2.":                                                    "Windowsプラン、8vCPU、メモリ8GB、Windows Server 2012 R2",
+	"Windows Plan, 16vCPU, 16GB Memory and Windows Server 2008 R2.":                                                  "Windowsプラン、16vCPU、メモリ16GB、Windows Server 2008 R2",
+
+	// audit
+	"Show the audit log of the operations(add, remove, power, label).\nThe audit log is stored in ~/.conoha-vps.d/audit.log as JSON lines.": "操作(add, remove, power, label)の監査ログを表示します。\n監査ログは ~/.conoha-vps.d/audit.log にJSON Linesで保存されています。",
+	"Show records after the time.\n(e.g. \"2015-01-01\" \"2015-01-01 12:00\" \"24h\"(24 hours ago))":                                        "指定した日時以降の記録を表示します。\n(例 \"2015-01-01\" \"2015-01-01 12:00\" \"24h\"(24時間前))",
+	"Show records before the time. The format is the same as --since.":                                                                      "指定した日時以前の記録を表示します。書式は --since と同じです。",
+	"Show records of the VPS. VPS-ID or label(wildcards \"*\" and \"?\" are available).":                                                    "指定したVPSの記録を表示します。VPS-IDかラベルを指定します(ワイルドカード \"*\" \"?\" が使えます)。",
+	"Show records of the operation. It should be one of following.\n(\"add\" \"remove\" \"power\" \"label\")":                               "指定した操作の記録を表示します。以下のいずれかを指定します。\n(\"add\" \"remove\" \"power\" \"label\")",
+	"Show the latest N records.": "最新のN件を表示します。",
+
+	// completion
+	"Output the shell completion script.\nSubcommands, options, VPS-IDs and values of the options are completed.\nVPS-IDs are completed from the cache of \"conoha list\".": "シェルの補完スクリプトを出力します。\nサブコマンド、オプション、VPS-ID、オプションの値を補完します。\nVPS-IDは \"conoha list\" のキャッシュから補完します。",
+	"\"bash\", \"zsh\" or \"fish\".":            "\"bash\" \"zsh\" \"fish\"のいずれか。",
+	"bash: Add the following line to ~/.bashrc": "bash: ~/.bashrc に以下の行を追加します。",
+	"zsh: Add the following line to ~/.zshrc":   "zsh: ~/.zshrc に以下の行を追加します。",
+	"fish:": "fish:",
+
+	// diff
+	"Show differences between two snapshots.\nIf SNAPSHOT-B is not set, SNAPSHOT-A is compared with the current state.\nSnapshots are created by \"conoha snapshot save\".": "二つのスナップショットの差分を表示します。\nSNAPSHOT-Bを指定しない場合は、SNAPSHOT-Aと現在の状態を比較します。\nスナップショットは\"conoha snapshot save\"で作成します。",
+	"Compare ServerStatus too.": "ServerStatusも比較します。",
+
+	// docs
+	"Generate man pages(section 1) and markdown reference of all commands.\nThey are written in the language of messages(--lang).": "全てのコマンドのmanページ(セクション1)とMarkdownのリファレンスを作成します。\nメッセージの言語(--lang)で作成します。",
+	"Directory to write man pages(conoha.1, conoha-list.1 ...).":                                                                   "manページ(conoha.1, conoha-list.1 ...)を作成するディレクトリ。",
+	"Directory to write markdown files(conoha.md, conoha-list.md ...).":                                                            "Markdownのファイル(conoha.md, conoha-list.md ...)を作成するディレクトリ。",
+	"Install man pages.":                          "manページをインストールします。",
+	"Generate the command reference in Japanese.": "日本語のコマンドリファレンスを作成します。",
+
+	// help
+	"Show usage of the command. It is the same as \"conoha COMMAND -h\".": "コマンドの使い方を表示します。\"conoha COMMAND -h\" と同じです。",
+
+	// label
+	"New label.": "新しいラベル。",
+
+	// list
+	"List VPS status.":  "VPSの一覧を表示します。",
+	"Show VPS-ID only.": "VPS-IDのみを表示します。",
+	"Verbose output(default is true).\nIt will be included the server status, but slowly.":                                       "詳細を表示します(デフォルトはtrue)。\nサーバーの状態も表示しますが、時間がかかります。",
+	"Show the details(IP address, CPU, memory, host server) of each VPS.\nThe details are fetched for every VPS, so it is slow.": "各VPSの詳細(IPアドレス、CPU、メモリ、収容ホスト)も表示します。\nVPSごとに詳細を取得するので時間がかかります。",
+	"Number of VPS to fetch the details concurrently with --wide.\nDefault is 4.":                                                "--wide で詳細を同時に取得するVPSの数。\nデフォルトは4です。",
+	"Show only VPS matching the expression. It can be specified multiple times.\n(e.g. 'status=Running' 'plan~\"Windows\"' 'label=web-*' 'created<2015-01-01')\nOperators are \"=\" \"!=\" \"~\"(contains) \"!~\" \"<\" \"<=\" \">\" \">=\".\n\"=\" and \"!=\" accept the wildcards \"*\" and \"?\".": "条件に一致するVPSのみを表示します。複数回指定できます。\n(例 'status=Running' 'plan~\"Windows\"' 'label=web-*' 'created<2015-01-01')\n演算子は \"=\" \"!=\" \"~\"(含む) \"!~\" \"<\" \"<=\" \">\" \">=\" です。\n\"=\" と \"!=\" ではワイルドカード \"*\" \"?\" が使えます。",
+	"Sort by the field. (e.g. label, plan, status, created)": "指定したフィールドで並べ替えます。(例 label, plan, status, created)",
+	"Reverse the order.":  "逆順に並べます。",
+	"Show at most N VPS.": "最大N台のVPSを表示します。",
+	"Colorize the server status. It should be one of following.\n(\"auto\" \"always\" \"never\") Default is \"auto\".\nIf \"auto\", colorize only when the output is a terminal.": "サーバーの状態に色を付けます。次のどれかを指定します。\n(\"auto\" \"always\" \"never\") デフォルトは\"auto\"です。\n\"auto\"の場合は端末に出力するときだけ色を付けます。",
+
+	// login
+	"Authenticate an account.\nIf account or password not set, you can input interactively.": "ログインします。\nアカウントやパスワードを指定しない場合は、対話的に入力できます。",
+	"ConoHa Account.": "ConoHaのアカウント。",
+	"Password.":       "パスワード。",
+
+	// logout
+	"Remove an authenticate file(~/.conoha-vps).\nIf there are other profiles, remove only the account and session of the profile in use.": "認証ファイル(~/.conoha-vps)を削除します。\n他にプロファイルがある場合は、使用中のプロファイルのアカウントとセッションだけを削除します。",
+
+	// profile
+	"Manage account profiles.\nEach profile has its own account and session, so switching profiles does not require login again.\nThe account logged in before creating profiles is the \"default\" profile.": "アカウントのプロファイルを管理します。\nプロファイルごとにアカウントとセッションを保存するので、切り替えても再ログインは不要です。\nプロファイルを作成する前にログインしていたアカウントは\"default\"プロファイルになります。",
+	"List profiles. The profile in use is marked with \"*\".":   "プロファイルの一覧を表示します。使用中のプロファイルには\"*\"が付きます。",
+	"Add a profile. It logs in when the profile is used first.": "プロファイルを追加します。ログインはプロファイルを最初に使うときに行います。",
+	"Switch the profile used by the following commands.":        "以降のコマンドで使うプロファイルを切り替えます。",
+	"Remove a profile.": "プロファイルを削除します。",
+
+	// power
+	"Power command. It should be one of following.\n(\"boot\" \"reboot\" \"shutdown\" \"stop\")": "電源操作のコマンド。次のどれかを指定します。\n(\"boot\" \"reboot\" \"shutdown\" \"stop\")",
+	"Attempt to send without prompting for confirmation.":                                        "確認せずに送信します。",
+
+	// remove
+	"Attempt to remove the VPS without prompting for confirmation.": "確認せずにVPSを削除します。",
+
+	// snapshot
+	"Save the details of all VPS to a file.\nThe snapshots can be compared by \"conoha diff\".": "全VPSの詳細をファイルに保存します。\nスナップショットは\"conoha diff\"で比較できます。",
+	"Save a snapshot. If NAME is not set, the current time is used.":                            "スナップショットを保存します。NAMEを指定しない場合は現在時刻を使います。",
+	"List snapshots.":    "スナップショットの一覧を表示します。",
+	"Remove a snapshot.": "スナップショットを削除します。",
+
+	// ssh-key
+	"Local filename the private key is stored.\nDefault is \"conoha-{Account}-{sshkey-no}.key\".": "秘密鍵を保存するファイル名。\nデフォルトは\"conoha-{Account}-{sshkey-no}.key\"です。",
+
+	// ssh
+	"Login to VPS via SSH.\nThere needs to be installed SSH client and all of the other options will be passed into SSH command.\nIt may not work on Windows.": "SSHでVPSにログインします。\nSSHクライアントがインストールされている必要があります。その他のオプションは全てSSHコマンドに渡されます。\nWindowsでは動作しない場合があります。",
+	"SSH username. Default is \"root\".": "SSHのユーザー名。デフォルトは\"root\"です。",
+
+	// stat
+	"Show VPS stats.":                      "VPSの詳細を表示します。",
+	"Include IPv6 informations in output.": "IPv6の情報も表示します。",
+
+	// wait
+	"Wait until the VPS satisfies the condition.\nThe progress is printed to stderr.":                                              "VPSが指定した状態になるまで待ちます。\n進捗は標準エラー出力に表示されます。",
+	"VPS-ID to wait for. It may be confirmed by \"conoha list\".":                                                                  "待機するVPSのVPS-ID。\"conoha list\"で確認できます。",
+	"Condition to wait for. It should be one of following.\n(\"running\" \"offline\" \"exists\" \"gone\") Default is \"running\".": "待機する条件。次のどれかを指定します。\n(\"running\" \"offline\" \"exists\" \"gone\") デフォルトは\"running\"です。",
+	"Timeout(e.g. \"90s\", \"10m\"). Default is 10m.":                                                                              "タイムアウト(例 \"90s\", \"10m\")。デフォルトは10mです。",
+	"Polling interval. Default is 5s.":                                                                                             "状態を確認する間隔。デフォルトは5sです。",
+	"The condition is satisfied.":                                                                                                  "条件を満たした",
+	"An error occurred.":                                                                                                           "エラーが発生した",
+	"Timed out.":                                                                                                                   "タイムアウトした",
+	"VPS is not found.":                                                                                                            "VPSが見つからない",
+
+	// batch
+	"Run commands in FILE line by line in the same session, like \"conoha shell\".\nEmpty lines and lines starting with \"#\" are ignored.\nNAME=value assigns the value to the variable, and NAME=$(COMMAND) assigns the output of the command.\nVariables can be referred as $NAME or ${NAME}. Environment variables are also available.\nStops at the first failed line unless --continue-on-error is specified.\nThe result of each line is printed to the standard error at the end.": "FILEに書いたコマンドを、\"conoha shell\"と同じく一つのセッションで1行ずつ実行します。\n空行と\"#\"で始まる行は無視します。\nNAME=valueで変数に値を、NAME=$(COMMAND)でコマンドの出力を代入します。\n変数は$NAMEか${NAME}で参照できます。環境変数も使えます。\n--continue-on-errorを指定しない場合は、最初に失敗した行で中止します。\n最後に各行の結果を標準エラー出力に表示します。",
+	"File of commands. If not set or \"-\", read from the standard input.": "コマンドを書いたファイル。指定しないか\"-\"の場合は標準入力から読みます。",
+	"Run the remaining lines even if a line failed.":                       "失敗した行があっても残りの行を実行します。",
+	"Run the file.": "ファイルを実行します。",
+	"The exit status of the first failed line. 0 if all lines succeeded.": "最初に失敗した行の終了コード。全ての行が成功した場合は0。",
+
+	// shell
+	"Read commands line by line and run them in the same session.\nThe login and the cache are kept while the shell is running, so each command runs faster.\nLine editing, history(Up, Down) and completion(Tab) are available.\n--output, --yes and --dry-run can be specified for each line. Type \"exit\" or Ctrl-D to quit.": "コマンドを1行ずつ読み、同じセッションで実行します。\nシェルの実行中はログインとキャッシュを保持するので、各コマンドを速く実行できます。\n行編集、履歴(↑↓)、補完(Tab)が使えます。\n--output、--yes、--dry-runは行ごとに指定できます。\"exit\"かCtrl-Dで終了します。",
+
+	// ui
+	"Show VPS list and the details of the selected VPS in full screen.\nServerStatus is refreshed periodically in the background.\nThe power commands, label and SSH can be operated by the keys.": "VPSの一覧と選択したVPSの詳細を全画面に表示します。\nServerStatusはバックグラウンドで定期的に更新します。\n電源操作、ラベルの変更、SSH接続をキーで操作できます。",
+	"Refresh interval. Default is 10s.": "更新する間隔。デフォルトは10sです。",
+	"KEYS":                              "キー",
+	"Select VPS.":                       "VPSを選択します。",
+	"Send \"boot\", \"reboot\", \"shutdown\" or \"stop\" command after the confirmation.": "確認してから\"boot\" \"reboot\" \"shutdown\" \"stop\"コマンドを送信します。",
+	"Change the label.": "ラベルを変更します。",
+	"Copy the IP address to the clipboard(the terminal should support OSC 52).": "IPアドレスをクリップボードにコピーします(端末がOSC 52に対応している必要があります)。",
+	"Refresh now.": "すぐに更新します。",
+	"Quit.":        "終了します。",
+
+	// watch
+	"Watch VPS list and statuses periodically.\nIt prints one JSON line to stdout for each change, until interrupted.\nThe field of \"changed\" event is one of following.\n(\"ServerStatus\" \"Label\" \"ServiceStatus\" \"DeleteDate\")": "VPSの一覧と状態を定期的に監視します。\n中断されるまで、変更があるたびにJSONを1行ずつ標準出力に出力します。\n\"changed\"イベントのfieldは次のどれかです。\n(\"ServerStatus\" \"Label\" \"ServiceStatus\" \"DeleteDate\")",
+	"Polling interval. Default is 1m.":                             "監視する間隔。デフォルトは1mです。",
+	"Max interval when errors occur continuously. Default is 10m.": "エラーが続いた場合の最大の間隔。デフォルトは10mです。",
+	"Print \"appeared\" events for existing VPS at start.":         "開始時に既存のVPSの\"appeared\"イベントを出力します。",
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*[a-zA-Z]`)
+
+// lib.T()(libの中ではT())に文字列リテラルを渡している箇所
+var translatedLiteral = regexp.MustCompile("(?:^|[^A-Za-z0-9_])T\\((\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`)")
+
+// ソースでlib.T()に渡している文字列(エラー、ログ、表の見出しなど)が全て翻訳されていること
+func TestMessagesJaComplete(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	libFiles, _ := filepath.Glob(filepath.Join("..", "lib", "*.go"))
+
+	for _, file := range append(files, libFiles...) {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, m := range translatedLiteral.FindAllStringSubmatch(string(b), -1) {
+			en, err := strconv.Unquote(m[1])
+			if err != nil {
+				t.Errorf("%s: %s", file, err)
+				continue
+			}
+			_, inMessages := messagesJa[en]
+			_, inUsages := usagesJa[en]
+			if !inMessages && !inUsages {
+				t.Errorf("%s: no translation for %q", file, en)
+			}
+		}
+	}
+}
+
+// 翻訳でフォーマットの引数の数が変わっていないこと
+func TestMessagesJaVerbs(t *testing.T) {
+	for _, catalog := range []map[string]string{messagesJa, usagesJa} {
+		for en, ja := range catalog {
+			if n, m := len(formatVerb.FindAllString(en, -1)), len(formatVerb.FindAllString(ja, -1)); n != m {
+				t.Errorf("number of verbs differs. [%s] [%s]", en, ja)
+			}
+		}
+	}
+}
+
+func TestServerStatusLang(t *testing.T) {
+	defer lib.SetLang(lib.LangEn)
+
+	if err := lib.SetLang(lib.LangJa); err != nil {
+		t.Fatal(err)
+	}
+	if s := ServerStatus(StatusRunning).String(); s != "稼働中" {
+		t.Errorf("status should be translated. [%s]", s)
+	}
+
+	// 機械向けの名前は翻訳しない
+	if s := ServerStatus(StatusRunning).Name(); s != "Running" {
+		t.Errorf("name should not be translated. [%s]", s)
+	}
+
+	e := &WaitTimeoutError{VmId: "aaa", Status: StatusOffline, Timeout: 10e9}
+	if s := e.Error(); s != `VPS(id=aaa)を10s待ちましたがタイムアウトしました。 最後の状態は"停止"です。` {
+		t.Errorf("wrong message. [%s]", s)
+	}
+
+	lib.SetLang(lib.LangEn)
+	if s := ServerStatus(StatusRunning).String(); s != "Running" {
+		t.Errorf("status should be English. [%s]", s)
+	}
+}
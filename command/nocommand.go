@@ -1,7 +1,9 @@
 package command
 
 import (
-	"fmt"
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
 )
 
 type Nocommand struct {
@@ -19,27 +21,19 @@ func (cmd *Nocommand) parseFlag() error {
 }
 
 func (cmd *Nocommand) Usage() {
-	fmt.Println(`Usage: conoha COMMAND [OPTIONS]
-
-DESCRIPTION
-    A CLI-Tool for ConoHa VPS.
-
-COMMANDS
-    add      Add VPS.
-    label    Change VPS label.
-    list     List VPS.
-    login    Authenticate an account.
-    logout   Remove an authenticate file(~/.conoha-vps).
-    power    Send power-command to VPS.
-    remove   Remove VPS.
-    ssh-key  Download and store SSH Private key.
-    ssh      Login to VPS via SSH.
-    stat     Display VPS information.
-    version  Display version.
-`)
+	writeUsage(os.Stdout, conohaInfo)
 }
 
 func (cmd *Nocommand) Run() error {
 	cmd.Usage()
+
+	// 未定義のコマンドが指定された場合はエラーにする
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "-h", "--help":
+		default:
+			return &UsageError{Err: errors.New(lib.T(`Undefined command "%s".`, os.Args[1]))}
+		}
+	}
 	return &ShowUsageError{}
 }
@@ -0,0 +1,319 @@
+package command
+
+// VPSを選択するピッカー
+// 入力した文字列でラベル、VPS-ID、プランをあいまい検索して絞り込む
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	runewidth "github.com/mattn/go-runewidth"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ピッカーに表示する最大の行数
+const pickerRows = 10
+
+type vpsPicker struct {
+	servers []*Vm
+	multi   bool
+
+	// 入力中の検索文字列と、それに一致したVPS
+	query   []rune
+	matches []*Vm
+
+	cursor int
+	offset int
+
+	// 複数選択でチェックしたVPS-ID
+	checked map[string]bool
+
+	color bool
+}
+
+func newVpsPicker(servers []*Vm, multi bool) *vpsPicker {
+	p := &vpsPicker{
+		servers: servers,
+		multi:   multi,
+		checked: map[string]bool{},
+		color:   lib.UseColor(lib.ColorAuto, os.Stderr),
+	}
+	p.filter()
+	return p
+}
+
+// 端末でVPSを選択する
+// ピッカーは標準エラー出力に表示するので、標準出力をリダイレクトしていても使える
+func (p *vpsPicker) run() ([]*Vm, error) {
+	if !lib.IsTerminal(os.Stdin) || !lib.IsTerminal(os.Stderr) {
+		return nil, errors.New(lib.T("Specify VPS-ID. VPS can not be selected because the terminal is not available."))
+	}
+
+	restore, err := lib.MakeRaw(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	// 前回描画した行数。再描画の時はその分だけカーソルを戻す
+	drawn := 0
+	draw := func(lines []string) {
+		s := ""
+		if drawn > 1 {
+			s += fmt.Sprintf("\x1b[%dA", drawn-1)
+		}
+		s += "\r\x1b[J" + strings.Join(lines, "\r\n")
+		fmt.Fprint(os.Stderr, s)
+		drawn = len(lines)
+	}
+
+	fmt.Fprint(os.Stderr, "\x1b[?25l")
+	defer func() {
+		draw([]string{})
+		fmt.Fprint(os.Stderr, "\x1b[?25h")
+	}()
+
+	for {
+		width, height := lib.TerminalSize(os.Stderr)
+		draw(p.render(width, height))
+
+		keys, err := lib.ReadKeys(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			done, err := p.handleKey(key)
+			if err != nil {
+				return nil, err
+			} else if done {
+				return p.result(), nil
+			}
+		}
+	}
+}
+
+// キー入力を処理する。選択が終わった場合はtrueを返す
+func (p *vpsPicker) handleKey(key lib.Key) (bool, error) {
+	switch key.Code {
+	case lib.KeyUp:
+		p.moveCursor(-1)
+	case lib.KeyDown:
+		p.moveCursor(1)
+	case lib.KeyTab:
+		// 複数選択の場合はチェックを切り替えて次に進む
+		if vm := p.current(); p.multi && vm != nil {
+			if p.checked[vm.Id] {
+				delete(p.checked, vm.Id)
+			} else {
+				p.checked[vm.Id] = true
+			}
+			p.moveCursor(1)
+		}
+	case lib.KeyEnter:
+		return len(p.result()) > 0, nil
+	case lib.KeyEsc, lib.KeyCtrlC:
+		return false, &DeclinedError{}
+	case lib.KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case lib.KeyCtrlU:
+		p.query = []rune{}
+		p.filter()
+	case lib.KeyCtrlW:
+		q := strings.TrimRightFunc(string(p.query), unicode.IsSpace)
+		if i := strings.LastIndexFunc(q, unicode.IsSpace); i >= 0 {
+			p.query = []rune(q[:i+1])
+		} else {
+			p.query = []rune{}
+		}
+		p.filter()
+	case lib.KeyNone:
+		if unicode.IsPrint(key.Rune) {
+			p.query = append(p.query, key.Rune)
+			p.filter()
+		}
+	}
+	return false, nil
+}
+
+func (p *vpsPicker) moveCursor(n int) {
+	p.cursor += n
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// カーソル位置のVPS
+func (p *vpsPicker) current() *Vm {
+	if p.cursor < 0 || p.cursor >= len(p.matches) {
+		return nil
+	}
+	return p.matches[p.cursor]
+}
+
+// 選択したVPSを返す
+// 複数選択でチェックしたVPSがあればそれを一覧の順番で、なければカーソル位置のVPSを返す
+func (p *vpsPicker) result() []*Vm {
+	result := []*Vm{}
+	for _, vm := range p.servers {
+		if p.checked[vm.Id] {
+			result = append(result, vm)
+		}
+	}
+	if len(result) == 0 {
+		if vm := p.current(); vm != nil {
+			result = append(result, vm)
+		}
+	}
+	return result
+}
+
+// 検索文字列でVPSを絞り込み、一致度の高い順に並べる
+func (p *vpsPicker) filter() {
+	words := strings.Fields(string(p.query))
+
+	matches := &pickerMatches{}
+	for _, vm := range p.servers {
+		if score, ok := vmFuzzyScore(vm, words); ok {
+			matches.servers = append(matches.servers, vm)
+			matches.scores = append(matches.scores, score)
+		}
+	}
+	sort.Stable(matches)
+
+	p.matches = matches.servers
+	p.cursor = 0
+	p.offset = 0
+}
+
+// 表示する行を返す
+func (p *vpsPicker) render(width int, height int) []string {
+	if width <= 0 {
+		width = 80
+	}
+
+	rows := pickerRows
+	if height > 0 && height-1 < rows {
+		rows = height - 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	// 検索文字列の入力欄
+	prompt := lib.T("Select VPS")
+	if p.multi {
+		prompt = lib.T("Select VPS (Tab to check)")
+	}
+	lines := []string{
+		runewidth.Truncate(fmt.Sprintf("%s (%d/%d)> %s_", prompt, len(p.matches), len(p.servers), string(p.query)), width, ""),
+	}
+
+	for i := p.offset; i < p.offset+rows && i < len(p.matches); i++ {
+		vm := p.matches[i]
+
+		marker := "  "
+		if i == p.cursor {
+			marker = "> "
+		}
+		if p.multi {
+			if p.checked[vm.Id] {
+				marker += "[x] "
+			} else {
+				marker += "[ ] "
+			}
+		}
+
+		status := lib.PadRight(vm.ServerStatus.String(), 12)
+		line := runewidth.Truncate(fmt.Sprintf("%s%s  %s  %s  %s", marker, lib.PadRight(vm.Label, 20), status, vm.Id, vm.Plan), width, "")
+
+		switch {
+		case !p.color:
+		case i == p.cursor:
+			line = "\x1b[1m" + line + "\x1b[0m"
+		default:
+			if n := strings.Index(line, status); n >= 0 {
+				line = line[:n] + lib.Colorize(status, vm.ServerStatus.Color()) + line[n+len(status):]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// 一致度で並べ替えるためのsort.Interface
+type pickerMatches struct {
+	servers []*Vm
+	scores  []int
+}
+
+func (m *pickerMatches) Len() int {
+	return len(m.servers)
+}
+
+func (m *pickerMatches) Less(i, j int) bool {
+	return m.scores[i] > m.scores[j]
+}
+
+func (m *pickerMatches) Swap(i, j int) {
+	m.servers[i], m.servers[j] = m.servers[j], m.servers[i]
+	m.scores[i], m.scores[j] = m.scores[j], m.scores[i]
+}
+
+// VPSが検索文字列の全ての単語に一致するか調べる
+// 単語ごとにラベル、VPS-ID、プランのうち最も一致度の高いもののスコアを合計する
+func vmFuzzyScore(vm *Vm, words []string) (int, bool) {
+	total := 0
+	for _, word := range words {
+		best, found := 0, false
+		for _, s := range []string{vm.Label, vm.Id, vm.Plan} {
+			if score, ok := fuzzyScore(word, s); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// patternの文字がsに順番に含まれているか調べる(大文字小文字は区別しない)
+// 含まれている場合は、連続して一致した文字や単語の先頭で一致した文字が多いほど高いスコアを返す
+func fuzzyScore(pattern string, s string) (int, bool) {
+	pr := []rune(strings.ToLower(pattern))
+	sr := []rune(strings.ToLower(s))
+
+	score, pi, prev := 0, 0, -2
+	for si := 0; si < len(sr) && pi < len(pr); si++ {
+		if sr[si] != pr[pi] {
+			continue
+		}
+
+		score++
+		if si == prev+1 {
+			score += 2
+		}
+		if si == 0 || !unicode.IsLetter(sr[si-1]) && !unicode.IsDigit(sr[si-1]) {
+			score += 3
+		}
+		prev = si
+		pi++
+	}
+	return score, pi == len(pr)
+}
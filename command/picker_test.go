@@ -0,0 +1,86 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("wb1", "web-01"); !ok {
+		t.Errorf("wb1 should match web-01.")
+	}
+	if _, ok := fuzzyScore("WEB", "web-01"); !ok {
+		t.Errorf("should ignore case.")
+	}
+	if _, ok := fuzzyScore("bew", "web-01"); ok {
+		t.Errorf("bew should not match web-01.")
+	}
+
+	// 連続した一致や単語の先頭での一致を優先する
+	a, _ := fuzzyScore("db", "db-01")
+	b, _ := fuzzyScore("db", "web-01-backup")
+	if a <= b {
+		t.Errorf("db-01 should be scored higher than web-01-backup. [%d, %d]", a, b)
+	}
+}
+
+func TestVpsPicker(t *testing.T) {
+	servers := []*Vm{
+		{Id: "f648a6646b7e7d91", Label: "web-01", Plan: "1GB Memory", ServerStatus: StatusRunning},
+		{Id: "a2ae45355615d641", Label: "db-01", Plan: "4GB Memory", ServerStatus: StatusOffline},
+		{Id: "0c1d7e05fd3f3c6a", Label: "web-02", Plan: "1GB Memory", ServerStatus: StatusRunning},
+	}
+
+	p := newVpsPicker(servers, true)
+	for _, r := range "web 1g" {
+		p.handleKey(lib.Key{Rune: r})
+	}
+	if len(p.matches) != 2 {
+		t.Errorf("should match 2 VPS. got %d", len(p.matches))
+	}
+
+	// Tabでチェックして次に進む
+	p.handleKey(lib.Key{Code: lib.KeyTab})
+	p.handleKey(lib.Key{Code: lib.KeyTab})
+	p.handleKey(lib.Key{Code: lib.KeyCtrlU})
+	if len(p.matches) != 3 {
+		t.Errorf("should match all VPS after clearing the query. got %d", len(p.matches))
+	}
+
+	done, err := p.handleKey(lib.Key{Code: lib.KeyEnter})
+	result := p.result()
+	if !done || err != nil || len(result) != 2 || result[0].Id != servers[0].Id || result[1].Id != servers[2].Id {
+		t.Errorf("should select checked VPS in order. [%v %v %v]", done, err, result)
+	}
+
+	// 一致するVPSがない場合はEnterで終了しない
+	p = newVpsPicker(servers, false)
+	for _, r := range "mail" {
+		p.handleKey(lib.Key{Rune: r})
+	}
+	if done, _ := p.handleKey(lib.Key{Code: lib.KeyEnter}); done {
+		t.Errorf("should not be done without any match.")
+	}
+
+	if _, err := p.handleKey(lib.Key{Code: lib.KeyEsc}); ExitCodeOf(err) != ExitCodeDeclined {
+		t.Errorf("Esc should cancel. [%v]", err)
+	}
+}
+
+func TestVpsSelectCandidatesStatus(t *testing.T) {
+	defer useFakePanel(0)()
+
+	cmd := &Vps{Command: &Command{browser: cpanel.NewBrowser().Fork()}, refresh: true}
+	servers, err := cmd.vpsSelectCandidates()
+	if err != nil || len(servers) != 2 {
+		t.Fatalf("got %v [%v]", servers, err)
+	}
+
+	// 選択肢にステータスを表示する
+	lines := strings.Join(newVpsPicker(servers, false).render(80, 10), "\n")
+	if strings.Count(lines, ServerStatus(StatusRunning).String()) != 2 {
+		t.Errorf("status should be shown.\n%s", lines)
+	}
+}
@@ -0,0 +1,220 @@
+package command
+
+// 外部コマンド(プラグイン)
+// 組み込みのコマンドがない場合、conoha foo はPATHにある conoha-foo を実行する
+// セッションやアカウントなどは環境変数でプラグインに渡す
+
+import (
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// プラグインの実行ファイル名の接頭辞
+const pluginPrefix = "conoha-"
+
+// 組み込みのコマンドと同じ名前のプラグインは使わない
+// conoha-vpsはこのツール自身の名前なので除く
+func isPluginName(name string) bool {
+	if name == "" || name == "vps" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	return LookupCommand(name) == nil
+}
+
+// PATHからプラグインを探す。見つからない場合はnilを返す
+func LookupPlugin(name string) *CommandInfo {
+	if !isPluginName(name) {
+		return nil
+	}
+
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return nil
+	}
+	return pluginInfo(name, path)
+}
+
+// プラグインのCommandInfo。Summaryは実行ファイルのパスにする
+func pluginInfo(name string, path string) *CommandInfo {
+	return &CommandInfo{
+		Name:     name,
+		Summary:  path,
+		Synopsis: "[ARGS]",
+		New:      func() Commander { return NewPlugin(name, path) },
+	}
+}
+
+// PATHにある全てのプラグインを名前の順に返す
+// 同じ名前のプラグインが複数ある場合は、PATHで先にあるものを使う
+func Plugins() []*CommandInfo {
+	plugins := []*CommandInfo{}
+	seen := map[string]bool{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if !strings.HasPrefix(file.Name(), pluginPrefix) || file.IsDir() || !isExecutable(file) {
+				continue
+			}
+
+			name := strings.TrimPrefix(file.Name(), pluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if seen[name] || !isPluginName(name) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, pluginInfo(name, filepath.Join(dir, file.Name())))
+		}
+	}
+
+	sort.Sort(pluginsByName(plugins))
+	return plugins
+}
+
+type pluginsByName []*CommandInfo
+
+func (p pluginsByName) Len() int           { return len(p) }
+func (p pluginsByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p pluginsByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// 実行できるファイルか
+// Windowsでは拡張子で判断する
+func isExecutable(file os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return file.Mode()&0111 != 0
+}
+
+type Plugin struct {
+	name string
+	path string
+	*Command
+}
+
+func NewPlugin(name string, path string) *Plugin {
+	return &Plugin{
+		name:    name,
+		path:    path,
+		Command: NewCommand(),
+	}
+}
+
+// 引数はそのままプラグインに渡す
+func (cmd *Plugin) parseFlag() error {
+	return nil
+}
+
+// プラグインの使い方はプラグインに --help を渡して表示する
+func (cmd *Plugin) Usage() {
+	cmd.exec([]string{"--help"})
+}
+
+func (cmd *Plugin) Run() error {
+	cmd.login()
+	return cmd.exec(os.Args[2:])
+}
+
+// セッションを渡せるように、ログインしていなければ再ログインする
+// プラグインがログインを必要としない場合もあるので、ログインできなくてもエラーにはしない
+func (cmd *Plugin) login() {
+	l := NewLogin()
+	if loggedIn, _ := l.LoggedIn(); !loggedIn {
+		lib.GetLogInstance().Debugf("Session is timed out. try relogin...")
+		l.Relogin()
+	}
+}
+
+// プラグインを実行する
+func (cmd *Plugin) exec(args []string) error {
+	c := exec.Command(cmd.path, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), cmd.environ()...)
+
+	lib.GetLogInstance().Debugf("run plugin: %s", cmd.path)
+
+	err := c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code := 1
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			code = status.ExitStatus()
+		}
+		return &PluginExitError{Name: cmd.name, Code: code}
+	}
+	return err
+}
+
+// プラグインに渡す環境変数
+func (cmd *Plugin) environ() []string {
+	opts := GetGlobalOptions()
+	configPath, _ := cmd.config.ConfigFilePath()
+	configDir, _ := cmd.config.ConfigDirPath()
+	sid := cmd.browser.BrowserInfo.Sid()
+
+	cookie := ""
+	if sid != "" {
+		cookie = cpanel.SESSION_NAME + "=" + sid
+	}
+
+	env := map[string]string{
+		"CONOHA_BIN":            os.Args[0],
+		"CONOHA_VERSION":        lib.Version,
+		"CONOHA_PROFILE":        cmd.config.Profile(),
+		"CONOHA_ACCOUNT":        cmd.config.Account,
+		"CONOHA_SESSION_ID":     sid,
+		"CONOHA_SESSION_COOKIE": cookie,
+		"CONOHA_CONFIG":         configPath,
+		"CONOHA_CONFIG_DIR":     configDir,
+		"CONOHA_OUTPUT":         opts.Output,
+		"CONOHA_LANG":           opts.Lang,
+		"CONOHA_ERROR_FORMAT":   opts.ErrorFormat,
+		"CONOHA_YES":            boolEnv(opts.Yes),
+		"CONOHA_DRY_RUN":        boolEnv(opts.DryRun),
+	}
+
+	result := []string{}
+	for key, value := range env {
+		result = append(result, key+"="+value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func boolEnv(b bool) string {
+	if b {
+		return "1"
+	}
+	return ""
+}
+
+// プラグインが0以外の終了コードで終了した場合のエラー
+// エラーはプラグインが出力しているので、mainでは出力せずに同じ終了コードで終了する
+type PluginExitError struct {
+	Name string
+	Code int
+}
+
+func (e *PluginExitError) Error() string {
+	return lib.T(`Plugin "%s" exited with status %d.`, e.Name, e.Code)
+}
+
+func (e *PluginExitError) ExitCode() ExitCode {
+	return ExitCode(e.Code)
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// テスト用のプラグインをPATHに置く
+func setupPlugins(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "conoha-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := map[string]string{
+		"conoha-hello": "#!/bin/sh\necho \"hello $CONOHA_ACCOUNT $*\"\n",
+		"conoha-fail":  "#!/bin/sh\nexit 42\n",
+		"conoha-list":  "#!/bin/sh\n",
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 実行できないファイルは無視する
+	ioutil.WriteFile(filepath.Join(dir, "conoha-text"), []byte("text"), 0644)
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return dir, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on Windows.")
+	}
+	dir, teardown := setupPlugins(t)
+	defer teardown()
+
+	info := LookupPlugin("hello")
+	if info == nil || info.Summary != filepath.Join(dir, "conoha-hello") {
+		t.Fatalf("got %+v", info)
+	}
+
+	// 組み込みのコマンドと同じ名前、実行できないファイルは使わない
+	for _, name := range []string{"list", "text", "vps", "-h", "../hello", "undefined"} {
+		if LookupPlugin(name) != nil {
+			t.Errorf("%s: should not be a plugin.", name)
+		}
+	}
+
+	names := []string{}
+	for _, p := range Plugins() {
+		if filepath.Dir(p.Summary) == dir {
+			names = append(names, p.Name)
+		}
+	}
+	if strings.Join(names, ",") != "fail,hello" {
+		t.Errorf("got %v", names)
+	}
+}
+
+func TestPluginRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on Windows.")
+	}
+	dir, teardown := setupPlugins(t)
+	defer teardown()
+	defer shareConfig(&lib.Config{Account: "C12345678"})()
+
+	cmd := NewPlugin("hello", filepath.Join(dir, "conoha-hello"))
+	out, err := captureStdout(func() error {
+		return cmd.exec([]string{"a", "b"})
+	})
+	if err != nil || out != "hello C12345678 a b\n" {
+		t.Errorf("got %q [%v]", out, err)
+	}
+
+	cmd = NewPlugin("fail", filepath.Join(dir, "conoha-fail"))
+	err = cmd.exec(nil)
+	if e, ok := err.(*PluginExitError); !ok || e.Code != 42 || ExitCodeOf(err) != 42 {
+		t.Errorf("got %#v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package command
+
+// アカウントのプロファイルを管理する
+// プロファイルごとにアカウントとセッションを設定ファイルに保存するので、切り替えても再ログインは不要
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"regexp"
+)
+
+// プロファイル名に使える文字
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+type Profile struct {
+	action   string
+	name     string
+	account  string
+	password string
+
+	*Command
+}
+
+func NewProfile() *Profile {
+	return &Profile{
+		Command: NewCommand(),
+	}
+}
+
+func (cmd *Profile) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.account, "account", "a", "", "")
+	fs.StringVarP(&cmd.password, "password", "p", "", "")
+	cmd.addOutputFlag(fs)
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if err := cmd.validateOutputFlag(); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	args := fs.Args()
+	if len(args) < 2 {
+		fs.Usage()
+		return errors.New(lib.T("Not enough arguments."))
+	}
+
+	cmd.action = args[1]
+	if len(args) > 2 {
+		cmd.name = args[2]
+	}
+
+	switch cmd.action {
+	case "add", "use", "remove":
+		if cmd.name == "" {
+			fs.Usage()
+			return errors.New(lib.T("Not enough arguments."))
+		}
+	case "list":
+	default:
+		fs.Usage()
+		return errors.New(lib.T(`Undefined action "%s".`, cmd.action))
+	}
+
+	return nil
+}
+
+func (cmd *Profile) Usage() {
+	printUsage("profile")
+}
+
+func (cmd *Profile) Run() error {
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	switch cmd.action {
+	case "list":
+		return cmd.list()
+	case "add":
+		err = cmd.add()
+	case "use":
+		err = cmd.use()
+	case "remove":
+		err = cmd.remove()
+	}
+	if err != nil {
+		return err
+	}
+
+	// 使用中のセッションも保存する
+	cmd.config.Sid = cmd.browser.BrowserInfo.Sid()
+	return cmd.config.Write()
+}
+
+// プロファイルを追加する
+// アカウントとパスワードを保存するだけで、ログインはプロファイルを使う最初のコマンドで行う
+func (cmd *Profile) add() error {
+	if !profileName.MatchString(cmd.name) {
+		return &UsageError{Err: errors.New(lib.T(`Invalid profile name "%s".`, cmd.name))}
+	} else if cmd.config.HasProfile(cmd.name) {
+		return &UsageError{Err: errors.New(lib.T(`Profile "%s" already exists.`, cmd.name))}
+	}
+
+	if cmd.account == "" || cmd.password == "" {
+		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける
+		l := &Login{Command: cmd.Command}
+		if err := l.inputAccountInfo(); err != nil {
+			return &UsageError{Err: errors.New(lib.T("Not enough arguments."))}
+		}
+		cmd.account, cmd.password = l.account, l.password
+	}
+
+	cmd.config.SetProfile(cmd.name, &lib.Profile{
+		Account:  cmd.account,
+		Password: cmd.password,
+	})
+	lib.GetLogInstance().Info(lib.T(`Profile "%s" is added.`, cmd.name))
+	return nil
+}
+
+// 以降のコマンドで使うプロファイルを切り替える
+// シェルの中では、シェルのセッションもそのプロファイルに切り替える
+func (cmd *Profile) use() error {
+	if !cmd.config.HasProfile(cmd.name) {
+		return &NotFoundError{lib.T(`Profile "%s" is not found.`, cmd.name)}
+	}
+
+	cmd.config.CurrentProfile = cmd.name
+	if cmd.name == lib.DefaultProfile {
+		cmd.config.CurrentProfile = ""
+	}
+
+	cmd.config.Sid = cmd.browser.BrowserInfo.Sid()
+	cmd.config.UseProfile(cmd.name)
+	cmd.browser.BrowserInfo.FixSid(cmd.config.Sid)
+	lib.GetCacheInstance().Configure(cmd.config)
+
+	lib.GetLogInstance().Info(lib.T(`Switched to profile "%s".`, cmd.name))
+	return nil
+}
+
+// プロファイルを削除する
+// デフォルトのプロファイルと使用中のプロファイルは削除できない
+func (cmd *Profile) remove() error {
+	if cmd.name == lib.DefaultProfile {
+		return &UsageError{Err: errors.New(lib.T(`The default profile can not be removed. Use "conoha logout" instead.`))}
+	} else if !cmd.config.HasProfile(cmd.name) {
+		return &NotFoundError{lib.T(`Profile "%s" is not found.`, cmd.name)}
+	} else if cmd.name == cmd.config.Profile() {
+		return &UsageError{Err: errors.New(lib.T(`Profile "%s" is in use.`, cmd.name))}
+	}
+
+	cmd.config.RemoveProfile(cmd.name)
+	lib.GetLogInstance().Info(lib.T(`Profile "%s" is removed.`, cmd.name))
+	return nil
+}
+
+// プロファイルの一覧を表示する
+func (cmd *Profile) list() error {
+	type summary struct {
+		Name    string `json:"name" yaml:"name"`
+		Account string `json:"account" yaml:"account"`
+		Current bool   `json:"current" yaml:"current"`
+	}
+
+	data := []*summary{}
+	items := []interface{}{}
+	for _, name := range cmd.config.ProfileNames() {
+		p := cmd.config.GetProfile(name)
+		sm := &summary{name, p.Account, name == cmd.config.Profile()}
+		data = append(data, sm)
+		items = append(items, sm)
+	}
+
+	if cmd.template != nil {
+		return lib.RenderTemplate(os.Stdout, cmd.template, items...)
+	}
+
+	if cmd.output != lib.OutputTable {
+		table := &lib.Table{
+			Header: []string{"name", "account", "current"},
+		}
+		for _, sm := range data {
+			table.Rows = append(table.Rows, []string{sm.Name, sm.Account, fmt.Sprint(sm.Current)})
+		}
+		return lib.Render(os.Stdout, cmd.output, data, table)
+	}
+
+	table := &lib.Table{
+		Header: []string{"", lib.T("Name"), lib.T("Account")},
+	}
+	for _, sm := range data {
+		mark := ""
+		if sm.Current {
+			mark = "*"
+		}
+		table.Rows = append(table.Rows, []string{mark, sm.Name, sm.Account})
+	}
+
+	return lib.RenderTable(os.Stdout, table, &lib.TableOptions{
+		MaxWidth: lib.TerminalWidth(os.Stdout),
+		Color:    lib.UseColor(lib.ColorAuto, os.Stdout),
+	})
+}
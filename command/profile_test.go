@@ -0,0 +1,141 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"github.com/mitchellh/go-homedir"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 設定ファイルをテスト用のディレクトリに作る
+func setupHome(t *testing.T, config string) func() {
+	dir, err := ioutil.TempDir("", "conoha-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != "" {
+		ioutil.WriteFile(filepath.Join(dir, lib.CONFIGFILE), []byte(config), 0600)
+	}
+
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", dir)
+	homedir.DisableCache = true
+	return func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+		os.RemoveAll(dir)
+	}
+}
+
+func readConfigFile(t *testing.T) map[string]interface{} {
+	c := &lib.Config{}
+	path, _ := c.ConfigFilePath()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]interface{}{}
+	if err = json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestConfigProfiles(t *testing.T) {
+	// プロファイルに対応する前の設定ファイル
+	defer setupHome(t, `{"Account":"C1","Password":"p1","Sid":"s1"}`)()
+
+	c := &lib.Config{}
+	c.Read()
+	if !c.UseProfile("") || c.Profile() != lib.DefaultProfile || c.Account != "C1" {
+		t.Fatalf("got %+v", c)
+	}
+	if c.UseProfile("staging") {
+		t.Errorf("undefined profile should not be used.")
+	}
+
+	c.SetProfile("staging", &lib.Profile{Account: "C2", Password: "p2"})
+	c.UseProfile("staging")
+	c.Sid = "s2"
+	if err := c.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	// トップレベルにはデフォルトのプロファイルを保存する
+	data := readConfigFile(t)
+	staging := data["Profiles"].(map[string]interface{})["staging"].(map[string]interface{})
+	if data["Account"] != "C1" || data["Sid"] != "s1" || staging["Account"] != "C2" || staging["Sid"] != "s2" {
+		t.Errorf("got %v", data)
+	}
+
+	c = &lib.Config{}
+	c.Read()
+	c.UseProfile("staging")
+	if c.Account != "C2" || c.Sid != "s2" || c.GetProfile(lib.DefaultProfile).Sid != "s1" {
+		t.Errorf("got %+v", c)
+	}
+	if strings.Join(c.ProfileNames(), ",") != "default,staging" {
+		t.Errorf("got %v", c.ProfileNames())
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	defer setupHome(t, `{"Account":"C1","Password":"p1","Sid":"s1"}`)()
+
+	config := &lib.Config{}
+	config.Read()
+	config.UseProfile("")
+	defer shareConfig(config)()
+
+	argv := os.Args
+	defer func() { os.Args = argv }()
+	run := func(args ...string) error {
+		os.Args = append([]string{"conoha", "profile"}, args...)
+		return NewProfile().Run()
+	}
+
+	for _, args := range [][]string{{"add", "staging", "-a", "C2", "-p", "p2"}, {"use", "staging"}} {
+		if err := run(args...); err != nil {
+			t.Fatalf("%v: %s", args, err)
+		}
+	}
+	if config.Profile() != "staging" || config.Account != "C2" || config.CurrentProfile != "staging" {
+		t.Errorf("got %+v", config)
+	}
+
+	tests := map[string]ExitCode{
+		"add staging":    ExitCodeUsage,
+		"add -x":         ExitCodeUsage,
+		"add ../x":       ExitCodeUsage,
+		"use production": ExitCodeNotFound,
+		"remove staging": ExitCodeUsage,
+		"remove default": ExitCodeUsage,
+		"rename staging": ExitCodeUsage,
+	}
+	for args, code := range tests {
+		if err := run(strings.Fields(args)...); ExitCodeOf(err) != code {
+			t.Errorf("%s: got %v", args, err)
+		}
+	}
+
+	out, err := captureStdout(func() error {
+		return run("list", "--format", "{{.Name}} {{.Account}} {{.Current}}")
+	})
+	if err != nil || out != "default C1 false\nstaging C2 true\n" {
+		t.Errorf("got %q [%v]", out, err)
+	}
+
+	if err = run("use", "default"); err != nil {
+		t.Fatal(err)
+	}
+	if err = run("remove", "staging"); err != nil {
+		t.Fatal(err)
+	}
+	if data := readConfigFile(t); data["Profiles"] != nil || data["CurrentProfile"] != nil || data["Account"] != "C1" {
+		t.Errorf("got %v", data)
+	}
+}
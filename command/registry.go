@@ -0,0 +1,620 @@
+package command
+
+// コマンドの一覧と定義
+// mainはここからコマンドを探して実行する
+// 使い方(-h)、manページ、Markdownのリファレンス、シェルの補完はこの定義から作る
+// 説明はすべて英語で書き、表示するときにlib.T()で翻訳する
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	flag "github.com/ogier/pflag"
+)
+
+type CommandInfo struct {
+	// コマンド名
+	Name string
+
+	// 別名(ls, rmなど)
+	Aliases []string
+
+	// 一行の説明
+	Summary string
+
+	// コマンド名より後ろの書式(例 "<VPS-ID> [OPTIONS]")
+	Synopsis string
+
+	// 詳しい説明。複数行でもよい
+	Description string
+
+	// 引数
+	Args []*ArgInfo
+
+	// オプション
+	Flags []*FlagInfo
+
+	// 最後に表示する節(例、終了コードなど)
+	Sections []*SectionInfo
+
+	// 実行する前にログインが必要か
+	RequireLogin bool
+
+	// 使い方(conoha -h)やドキュメントに表示しない
+	Hidden bool
+
+	// 引数にVPS-IDを取るか。シェルの補完に使う
+	VpsArg bool
+
+	// コマンドを作成する
+	New func() Commander
+}
+
+// コマンドの引数
+type ArgInfo struct {
+	Name        string
+	Description string
+}
+
+// コマンドのオプション
+type FlagInfo struct {
+	// 長い名前(--なし)。ない場合は空
+	Name string
+
+	// 短い名前(-なし)。ない場合は空
+	Short string
+
+	// 値を取るか
+	Value bool
+
+	// 値の候補
+	Values []string
+
+	// 説明。複数行でもよい
+	Description string
+
+	// 値の例(翻訳しない)
+	Example string
+}
+
+// 使い方の節(EXAMPLE、EXIT STATUSなど)
+type SectionInfo struct {
+	Title string
+	Items []*SectionItem
+}
+
+// 節の項目
+// Termがある場合は「Term  Text」の形式で、Commandがある場合はTextの下に例として表示する
+// TextとTitleは翻訳し、TermとCommandは翻訳しない
+type SectionItem struct {
+	Term    string
+	Text    string
+	Command string
+}
+
+// 複数のオプションの定義をまとめる
+func flags(sets ...[]*FlagInfo) []*FlagInfo {
+	result := []*FlagInfo{}
+	for _, set := range sets {
+		result = append(result, set...)
+	}
+	return result
+}
+
+var outputFormats = []string{"table", "json", "yaml", "csv", "tsv"}
+
+// コマンドのFlagSetを作成する
+// フラグの説明はFlagInfoだけに書く。使い方はregistryから表示するので、FlagSetのフラグには説明を付けない
+func newFlagSet(usage func()) *flag.FlagSet {
+	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
+	fs.Usage = usage
+	if flagSetCreated != nil {
+		flagSetCreated(fs)
+	}
+	return fs
+}
+
+// FlagSetを作成したときに呼ばれる関数。テストでFlagSetとFlagInfoを比べるために使う
+var flagSetCreated func(fs *flag.FlagSet)
+
+var (
+	helpFlags = []*FlagInfo{
+		{Name: "help", Short: "h", Description: "Show usage."},
+	}
+
+	// Vps.addCacheFlags()
+	cacheFlags = []*FlagInfo{
+		{Name: "refresh", Description: "Ignore the cache and fetch the latest information."},
+		{Name: "no-cache", Description: "Do not read or write the cache."},
+	}
+)
+
+// Command.addOutputFlag()
+// exampleは --format の例
+func outputFlags(example string) []*FlagInfo {
+	return []*FlagInfo{
+		{Name: "output", Short: "o", Value: true, Values: outputFormats, Description: `Output format. It should be one of following.
+("table" "json" "yaml" "csv" "tsv") Default is "table".`},
+		{Name: "format", Value: true, Example: example, Description: `Format the output using the Go template.
+The functions "join" "upper" "lower" "date" "json" are available.`},
+	}
+}
+
+// 引数のVPS-ID(省略した場合は一覧から選択する)
+var vpsArg = &ArgInfo{
+	Name: "<VPS-ID>",
+	Description: `(Optional) VPS-ID. It may be confirmed by "conoha list".
+The label, a unique prefix of VPS-ID, the service ID or an IP address is also accepted.
+If not set, it will be selected interactively from VPS list.`,
+}
+
+// 全体のオプション(GlobalOptions)
+var globalFlags = []*FlagInfo{
+	{Name: "lang", Value: true, Values: []string{"en", "ja"}, Description: `Language of messages. It should be "en" or "ja".
+If not set, it is detected from LANG environment variable.`},
+	{Name: "error-format", Value: true, Values: []string{"text", "json"}, Description: `Format of the error message written to stderr.
+It should be "text" or "json". Default is "text".`},
+	{Name: "log-level", Value: true, Values: []string{"debug", "info", "warning", "error"}, Description: `Log level. It should be "debug", "info", "warning" or "error".
+Default is "info".`},
+	{Name: "log-format", Value: true, Values: []string{"text", "simple", "json"}, Description: `Log format. It should be "text", "simple" or "json".
+Default is "text".`},
+	{Name: "log-file", Value: true, Description: "Also append log entries to the file."},
+	{Name: "profile", Value: true, Description: `Account profile to use.
+If not set, CONOHA_PROFILE environment variable or the profile selected by "conoha profile use" is used.`},
+	{Name: "output", Value: true, Values: outputFormats, Description: "Default output format of the commands that have -o option."},
+	{Name: "debug", Description: "Same as --log-level debug."},
+	{Name: "yes", Description: `Answer "yes" to all confirmations(same as -f of power and remove).`},
+	{Name: "dry-run", Description: `Do not send the request of add, remove, power and label.
+Show the parameters of the request instead(passwords are masked).`},
+}
+
+// conohaコマンド自体の定義(conoha -h と conoha.1)
+// コマンドの一覧はcommandsから作る
+var conohaInfo = &CommandInfo{
+	Name:     "conoha",
+	Summary:  "A CLI-Tool for ConoHa VPS.",
+	Synopsis: "COMMAND [OPTIONS]",
+	Description: `A CLI-Tool for ConoHa VPS.
+Global options can be placed anywhere in the command line.
+Run "conoha help COMMAND" to show usage of the command.`,
+	Flags: globalFlags,
+	Sections: []*SectionInfo{
+		{Title: "EXIT STATUS", Items: []*SectionItem{
+			{Term: "0", Text: "Success."},
+			{Term: "1", Text: "Error."},
+			{Term: "2", Text: "Timeout."},
+			{Term: "3", Text: "Not found."},
+			{Term: "4", Text: "Usage error."},
+			{Term: "5", Text: "Authentication failure."},
+			{Term: "6", Text: "Unexpected HTML of the control panel."},
+			{Term: "7", Text: "Declined the confirmation."},
+		}},
+	},
+}
+
+// 全てのコマンド。使い方(conoha -h)にはこの順番で表示される
+var commands = []*CommandInfo{
+	{
+		Name:         "add",
+		Summary:      "Add VPS.",
+		Synopsis:     "[OPTIONS]",
+		Description:  "Add VPS to your account.",
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "type", Short: "t", Value: true, Values: []string{"basic", "windows"}, Description: `VPS Type. It should be "basic" or "windows".
+If not set, it will be "basic".`},
+			{Name: "plan", Short: "p", Value: true, Values: []string{"1", "2", "4", "8", "16"}, Description: `VPS Plan.
+It allows only numeric(1=1G, 2=2G ... 16=16G).`},
+			{Name: "image", Short: "i", Value: true, Values: []string{"centos", "wordpress", "windows2012", "windows2008"}, Description: `Template image. It should be one of the following.
+("centos" "wordpress" "windows2012" "windows2008")`},
+			{Name: "password", Short: "P", Value: true, Description: `Root password.
+If the VPS Type is "basic" only.`},
+			{Name: "sshkey-no", Short: "s", Value: true, Description: `SSH Key number. Default is 1.
+If the number of keys is one, it will be ignored.`},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "EXAMPLE", Items: []*SectionItem{
+				{Text: "Standard Plan, 2vCPU, 1GB Memory and CentOS6.5.", Command: "conoha add -t basic -p 1 -i centos -P {password}"},
+				{Text: "Standard Plan, 4vCPU, 4GB Memory and CentOS6.5 + nginx + WordPress.", Command: "conoha add -t basic -p 4 -i wordpress -P {password}"},
+				{Text: "Windows Plan, 8vCPU, 8GB Memory and Windows Server 2012 R2.", Command: "conoha add -t windows -p 8 -i windows2012"},
+				{Text: "Windows Plan, 16vCPU, 16GB Memory and Windows Server 2008 R2.", Command: "conoha add -t windows -p 16 -i windows2008"},
+			}},
+		},
+		New: func() Commander { return NewVpsAdd() },
+	},
+	{
+		Name:     "audit",
+		Summary:  "Show the audit log of the operations.",
+		Synopsis: "[OPTIONS]",
+		Description: `Show the audit log of the operations(add, remove, power, label).
+The audit log is stored in ~/.conoha-vps.d/audit.log as JSON lines.`,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "since", Value: true, Description: `Show records after the time.
+(e.g. "2015-01-01" "2015-01-01 12:00" "24h"(24 hours ago))`},
+			{Name: "until", Value: true, Description: "Show records before the time. The format is the same as --since."},
+			{Name: "vps", Value: true, Description: `Show records of the VPS. VPS-ID or label(wildcards "*" and "?" are available).`},
+			{Name: "operation", Value: true, Values: lib.AuditOperations, Description: `Show records of the operation. It should be one of following.
+("add" "remove" "power" "label")`},
+			{Name: "limit", Short: "n", Value: true, Description: "Show the latest N records."},
+		}, outputFlags(`{{.Time}}\t{{.Operation}}\t{{.VpsId}}`)),
+		New: func() Commander { return NewAudit() },
+	},
+	{
+		Name:     "batch",
+		Summary:  "Run commands written in a file.",
+		Synopsis: "[FILE] [OPTIONS]",
+		Description: `Run commands in FILE line by line in the same session, like "conoha shell".
+Empty lines and lines starting with "#" are ignored.
+NAME=value assigns the value to the variable, and NAME=$(COMMAND) assigns the output of the command.
+Variables can be referred as $NAME or ${NAME}. Environment variables are also available.
+Stops at the first failed line unless --continue-on-error is specified.
+The result of each line is printed to the standard error at the end.`,
+		RequireLogin: true,
+		Args: []*ArgInfo{
+			{Name: "[FILE]", Description: `File of commands. If not set or "-", read from the standard input.`},
+		},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "continue-on-error", Description: "Run the remaining lines even if a line failed."},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "EXAMPLE", Items: []*SectionItem{
+				{Command: "# provision.txt\nLABEL=web-03\nadd -t basic -p 1 -i centos -P $ROOT_PASSWORD\nID=$(list --sort created --reverse --limit 1 --format '{{.Id}}')\nwait $ID --status running\nlabel $ID -l $LABEL"},
+				{Text: "Run the file.", Command: "conoha batch provision.txt"},
+			}},
+			{Title: "EXIT STATUS", Items: []*SectionItem{
+				{Text: "The exit status of the first failed line. 0 if all lines succeeded."},
+			}},
+		},
+		New: func() Commander { return NewBatch() },
+	},
+	{
+		Name:     "completion",
+		Summary:  "Output the shell completion script.",
+		Synopsis: "<SHELL>",
+		Description: `Output the shell completion script.
+Subcommands, options, VPS-IDs and values of the options are completed.
+VPS-IDs are completed from the cache of "conoha list".`,
+		Args: []*ArgInfo{
+			{Name: "<SHELL>", Description: `"bash", "zsh" or "fish".`},
+		},
+		Flags: helpFlags,
+		Sections: []*SectionInfo{
+			{Title: "EXAMPLE", Items: []*SectionItem{
+				{Text: "bash: Add the following line to ~/.bashrc", Command: `eval "$(conoha completion bash)"`},
+				{Text: "zsh: Add the following line to ~/.zshrc", Command: `eval "$(conoha completion zsh)"`},
+				{Text: "fish:", Command: "conoha completion fish > ~/.config/fish/completions/conoha.fish"},
+			}},
+		},
+		New: func() Commander { return NewCompletion() },
+	},
+	{
+		Name:     "diff",
+		Summary:  "Show differences between snapshots.",
+		Synopsis: "<SNAPSHOT-A> [<SNAPSHOT-B>] [OPTIONS]",
+		Description: `Show differences between two snapshots.
+If SNAPSHOT-B is not set, SNAPSHOT-A is compared with the current state.
+Snapshots are created by "conoha snapshot save".`,
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "include-status", Short: "s", Description: "Compare ServerStatus too."},
+		}),
+		New: func() Commander { return NewVpsDiff() },
+	},
+	{
+		Name:     "docs",
+		Summary:  "Generate man pages and markdown reference.",
+		Synopsis: "[OPTIONS]",
+		Description: `Generate man pages(section 1) and markdown reference of all commands.
+They are written in the language of messages(--lang).`,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "man", Value: true, Description: "Directory to write man pages(conoha.1, conoha-list.1 ...)."},
+			{Name: "markdown", Value: true, Description: "Directory to write markdown files(conoha.md, conoha-list.md ...)."},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "EXAMPLE", Items: []*SectionItem{
+				{Text: "Install man pages.", Command: "conoha docs --man /usr/local/share/man/man1"},
+				{Text: "Generate the command reference in Japanese.", Command: "conoha docs --lang ja --markdown docs"},
+			}},
+		},
+		New: func() Commander { return NewDocs() },
+	},
+	{
+		Name:        "help",
+		Summary:     "Show usage of the command.",
+		Synopsis:    "[COMMAND]",
+		Description: `Show usage of the command. It is the same as "conoha COMMAND -h".`,
+		Flags:       helpFlags,
+		New:         func() Commander { return NewHelp() },
+	},
+	{
+		Name:         "label",
+		Summary:      "Change VPS label.",
+		Synopsis:     "<VPS-ID> [OPTIONS]",
+		Description:  "Change VPS label.",
+		RequireLogin: true,
+		Args:         []*ArgInfo{vpsArg},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "label", Short: "l", Value: true, Description: "New label."},
+		}),
+		VpsArg: true,
+		New:    func() Commander { return NewVpsLabel() },
+	},
+	{
+		Name:         "list",
+		Aliases:      []string{"ls"},
+		Summary:      "List VPS.",
+		Synopsis:     "[OPTIONS]",
+		Description:  "List VPS status.",
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "id-only", Short: "i", Description: "Show VPS-ID only."},
+		}, outputFlags(`{{.Id}}\t{{.Label}}\t{{date "2006/01/02" .CreatedAt}}`), []*FlagInfo{
+			{Name: "Verbose", Short: "v", Description: `Verbose output(default is true).
+It will be included the server status, but slowly.`},
+			{Name: "wide", Short: "w", Description: `Show the details(IP address, CPU, memory, host server) of each VPS.
+The details are fetched for every VPS, so it is slow.`},
+			{Name: "parallel", Short: "p", Value: true, Description: `Number of VPS to fetch the details concurrently with --wide.
+Default is 4.`},
+			{Name: "filter", Short: "f", Value: true, Description: `Show only VPS matching the expression. It can be specified multiple times.
+(e.g. 'status=Running' 'plan~"Windows"' 'label=web-*' 'created<2015-01-01')
+Operators are "=" "!=" "~"(contains) "!~" "<" "<=" ">" ">=".
+"=" and "!=" accept the wildcards "*" and "?".`},
+			{Name: "sort", Short: "s", Value: true, Values: vmListFields, Description: "Sort by the field. (e.g. label, plan, status, created)"},
+			{Name: "reverse", Short: "r", Description: "Reverse the order."},
+			{Name: "limit", Short: "n", Value: true, Description: "Show at most N VPS."},
+			{Name: "color", Value: true, Values: []string{"auto", "always", "never"}, Description: `Colorize the server status. It should be one of following.
+("auto" "always" "never") Default is "auto".
+If "auto", colorize only when the output is a terminal.`},
+		}, cacheFlags),
+		New: func() Commander { return NewVpsList() },
+	},
+	{
+		Name:     "login",
+		Summary:  "Authenticate an account.",
+		Synopsis: "[OPTIONS]",
+		Description: `Authenticate an account.
+If account or password not set, you can input interactively.`,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "account", Short: "a", Value: true, Description: "ConoHa Account."},
+			{Name: "password", Short: "p", Value: true, Description: "Password."},
+		}),
+		New: func() Commander { return NewLogin() },
+	},
+	{
+		Name:     "logout",
+		Summary:  "Remove an authenticate file(~/.conoha-vps).",
+		Synopsis: "[OPTIONS]",
+		Description: `Remove an authenticate file(~/.conoha-vps).
+If there are other profiles, remove only the account and session of the profile in use.`,
+		Flags: helpFlags,
+		New:   func() Commander { return NewLogout() },
+	},
+	{
+		Name:         "power",
+		Summary:      "Send power-command to VPS.",
+		Synopsis:     "<VPS-ID> [OPTIONS]",
+		Description:  "Send power-command to VPS.",
+		RequireLogin: true,
+		Args:         []*ArgInfo{vpsArg},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "command", Short: "c", Value: true, Values: []string{"boot", "reboot", "shutdown", "stop"}, Description: `Power command. It should be one of following.
+("boot" "reboot" "shutdown" "stop")`},
+			{Name: "force-send", Short: "f", Description: "Attempt to send without prompting for confirmation."},
+		}),
+		VpsArg: true,
+		New:    func() Commander { return NewVpsPower() },
+	},
+	{
+		Name:     "profile",
+		Summary:  "Manage account profiles.",
+		Synopsis: "<ACTION> [NAME] [OPTIONS]",
+		Description: `Manage account profiles.
+Each profile has its own account and session, so switching profiles does not require login again.
+The account logged in before creating profiles is the "default" profile.`,
+		Args: []*ArgInfo{
+			{Name: "list", Description: "List profiles. The profile in use is marked with \"*\"."},
+			{Name: "add NAME", Description: "Add a profile. It logs in when the profile is used first."},
+			{Name: "use NAME", Description: "Switch the profile used by the following commands."},
+			{Name: "remove NAME", Description: "Remove a profile."},
+		},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "account", Short: "a", Value: true, Description: "ConoHa Account."},
+			{Name: "password", Short: "p", Value: true, Description: "Password."},
+		}, outputFlags(`{{.Name}} {{.Account}}`)),
+		New: func() Commander { return NewProfile() },
+	},
+	{
+		Name:         "remove",
+		Aliases:      []string{"rm"},
+		Summary:      "Remove VPS.",
+		Synopsis:     "<VPS-ID> [OPTIONS]",
+		Description:  "Remove VPS.",
+		RequireLogin: true,
+		Args:         []*ArgInfo{vpsArg},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "force-remove", Short: "f", Description: "Attempt to remove the VPS without prompting for confirmation."},
+		}),
+		VpsArg: true,
+		New:    func() Commander { return NewVpsRemove() },
+	},
+	{
+		Name:     "shell",
+		Summary:  "Run commands interactively.",
+		Synopsis: "[OPTIONS]",
+		Description: `Read commands line by line and run them in the same session.
+The login and the cache are kept while the shell is running, so each command runs faster.
+Line editing, history(Up, Down) and completion(Tab) are available.
+--output, --yes and --dry-run can be specified for each line. Type "exit" or Ctrl-D to quit.`,
+		RequireLogin: true,
+		Flags:        helpFlags,
+		Sections: []*SectionInfo{
+			{Title: "EXAMPLE", Items: []*SectionItem{
+				{Command: "$ conoha shell\nconoha> list\nconoha> power web-01 -c reboot --yes\nconoha> stat web-01 -o json\nconoha> exit"},
+			}},
+		},
+		New: func() Commander { return NewShell() },
+	},
+	{
+		Name:     "snapshot",
+		Summary:  "Save the details of all VPS.",
+		Synopsis: "<ACTION> [NAME] [OPTIONS]",
+		Description: `Save the details of all VPS to a file.
+The snapshots can be compared by "conoha diff".`,
+		RequireLogin: true,
+		Args: []*ArgInfo{
+			{Name: "save [NAME]", Description: "Save a snapshot. If NAME is not set, the current time is used."},
+			{Name: "list", Description: "List snapshots."},
+			{Name: "remove NAME", Description: "Remove a snapshot."},
+		},
+		Flags: flags(outputFlags(`{{.Name}} {{.Vps}}`), helpFlags),
+		New:   func() Commander { return NewVpsSnapshot() },
+	},
+	{
+		Name:         "ssh-key",
+		Summary:      "Download and store SSH Private key.",
+		Synopsis:     "[OPTIONS]",
+		Description:  "Download and store SSH Private key.",
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "path", Short: "f", Value: true, Description: `Local filename the private key is stored.
+Default is "conoha-{Account}-{sshkey-no}.key".`},
+			{Name: "sshkey-no", Short: "s", Value: true, Description: `SSH Key number. Default is 1.
+If the number of keys is one, it will be ignored.`},
+		}),
+		New: func() Commander { return NewSshKey() },
+	},
+	{
+		Name:     "ssh",
+		Summary:  "Login to VPS via SSH.",
+		Synopsis: "<VPS-ID> [OPTIONS ...]",
+		Description: `Login to VPS via SSH.
+There needs to be installed SSH client and all of the other options will be passed into SSH command.
+It may not work on Windows.`,
+		RequireLogin: true,
+		Args:         []*ArgInfo{vpsArg},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Short: "u", Value: true, Description: `SSH username. Default is "root".`},
+		}, cacheFlags),
+		VpsArg: true,
+		New:    func() Commander { return NewSsh() },
+	},
+	{
+		Name:         "stat",
+		Aliases:      []string{"show"},
+		Summary:      "Display VPS information.",
+		Synopsis:     "<VPS-ID> [OPTIONS]",
+		Description:  "Show VPS stats.",
+		RequireLogin: true,
+		Args:         []*ArgInfo{vpsArg},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "include-ipv6", Short: "6", Description: "Include IPv6 informations in output."},
+		}, outputFlags(`{{.IPv4}} {{join .IPv6 ","}}`), cacheFlags),
+		VpsArg: true,
+		New:    func() Commander { return NewVpsStat() },
+	},
+	{
+		Name:     "ui",
+		Summary:  "Show the dashboard of VPS in the terminal.",
+		Synopsis: "[OPTIONS]",
+		Description: `Show VPS list and the details of the selected VPS in full screen.
+ServerStatus is refreshed periodically in the background.
+The power commands, label and SSH can be operated by the keys.`,
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "interval", Short: "i", Value: true, Description: "Refresh interval. Default is 10s."},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "KEYS", Items: []*SectionItem{
+				{Term: "Up, Down, k, j", Text: "Select VPS."},
+				{Term: "b, r, s, f", Text: `Send "boot", "reboot", "shutdown" or "stop" command after the confirmation.`},
+				{Term: "l", Text: "Change the label."},
+				{Term: "Enter", Text: "Login to VPS via SSH."},
+				{Term: "c", Text: "Copy the IP address to the clipboard(the terminal should support OSC 52)."},
+				{Term: "u", Text: "Refresh now."},
+				{Term: "q, Esc", Text: "Quit."},
+			}},
+		},
+		New: func() Commander { return NewVpsUi() },
+	},
+	{
+		Name:        "version",
+		Summary:     "Display version.",
+		Description: "Display version.",
+		New:         func() Commander { return NewVersion() },
+	},
+	{
+		Name:     "wait",
+		Summary:  "Wait until VPS satisfies the condition.",
+		Synopsis: "<VPS-ID> [OPTIONS]",
+		Description: `Wait until the VPS satisfies the condition.
+The progress is printed to stderr.`,
+		RequireLogin: true,
+		Args: []*ArgInfo{
+			{Name: "<VPS-ID>", Description: `VPS-ID to wait for. It may be confirmed by "conoha list".`},
+		},
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "status", Short: "s", Value: true, Values: []string{WaitRunning, WaitOffline, WaitExists, WaitGone}, Description: `Condition to wait for. It should be one of following.
+("running" "offline" "exists" "gone") Default is "running".`},
+			{Name: "timeout", Short: "t", Value: true, Description: `Timeout(e.g. "90s", "10m"). Default is 10m.`},
+			{Name: "interval", Short: "i", Value: true, Description: "Polling interval. Default is 5s."},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "EXIT STATUS", Items: []*SectionItem{
+				{Term: "0", Text: "The condition is satisfied."},
+				{Term: "1", Text: "An error occurred."},
+				{Term: "2", Text: "Timed out."},
+				{Term: "3", Text: "VPS is not found."},
+			}},
+		},
+		VpsArg: true,
+		New:    func() Commander { return NewVpsWait() },
+	},
+	{
+		Name:     "watch",
+		Summary:  "Watch VPS and print changes as JSON lines.",
+		Synopsis: "[OPTIONS]",
+		Description: `Watch VPS list and statuses periodically.
+It prints one JSON line to stdout for each change, until interrupted.
+The field of "changed" event is one of following.
+("ServerStatus" "Label" "ServiceStatus" "DeleteDate")`,
+		RequireLogin: true,
+		Flags: flags(helpFlags, []*FlagInfo{
+			{Name: "interval", Short: "i", Value: true, Description: "Polling interval. Default is 1m."},
+			{Name: "max-backoff", Short: "b", Value: true, Description: "Max interval when errors occur continuously. Default is 10m."},
+			{Name: "emit-initial", Short: "a", Description: `Print "appeared" events for existing VPS at start.`},
+		}),
+		Sections: []*SectionInfo{
+			{Title: "OUTPUT", Items: []*SectionItem{
+				{Command: `{"time":"...","event":"appeared","id":"...","label":"..."}
+{"time":"...","event":"disappeared","id":"...","label":"..."}
+{"time":"...","event":"changed","id":"...","label":"...","field":"ServerStatus","old":"Running","new":"Offline"}`},
+			}},
+		},
+		New: func() Commander { return NewVpsWatch() },
+	},
+	{
+		// シェルの補完スクリプトから呼ばれる
+		Name:   CompleteCommandName,
+		Hidden: true,
+		New:    func() Commander { return NewComplete() },
+	},
+}
+
+// 全てのコマンドを返す
+func Commands() []*CommandInfo {
+	return commands
+}
+
+// コマンド名か別名からコマンドを探す
+// 見つからない場合はnilを返す
+func LookupCommand(name string) *CommandInfo {
+	for _, info := range commands {
+		if info.Name == name {
+			return info
+		}
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return info
+			}
+		}
+	}
+	return nil
+}
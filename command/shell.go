@@ -0,0 +1,498 @@
+package command
+
+// 対話的にコマンドを実行するシェル
+// 設定の読み書きとログインの確認はシェルの開始時と終了時だけにして、セッションとキャッシュを使い回す
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// 履歴を保存するファイル(設定ディレクトリ内)
+	shellHistoryFile = "shell_history"
+
+	// 保存しておく履歴の件数
+	shellHistorySize = 1000
+
+	// 履歴に保存するときのパスワードの伏せ字
+	shellHistoryRedacted = "********"
+
+	// 前回のログインの確認からこの時間が経っていたら、コマンドを実行する前にもう一度確認する
+	shellLoginCheckInterval = 5 * time.Minute
+)
+
+// シェルの実行中は、シェルの中で実行する全てのコマンドでこの設定を使う
+// セッション(Browser)はもともと共有されているので、設定ファイルを読み直してセッションIDを上書きしないようにする
+var sharedConfig *lib.Config
+
+// シェルの中で実行するコマンドでconfigを共有する
+// 戻り値の関数を呼ぶと共有をやめる。既に共有している場合(シェルの中でbatchを実行した場合など)は何もしない
+func shareConfig(config *lib.Config) func() {
+	if sharedConfig != nil {
+		return func() {}
+	}
+	sharedConfig = config
+	return func() { sharedConfig = nil }
+}
+
+type Shell struct {
+	// 最後にログインを確認した時刻
+	loginChecked time.Time
+
+	// logoutコマンドを実行した
+	loggedOut bool
+
+	*Vps
+}
+
+func NewShell() *Shell {
+	return &Shell{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *Shell) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+	return nil
+}
+
+func (cmd *Shell) Usage() {
+	printUsage("shell")
+}
+
+func (cmd *Shell) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	// シェルを開始する前にログインは確認済み
+	cmd.loginChecked = time.Now()
+
+	defer shareConfig(cmd.config)()
+
+	// 端末でない場合は行編集をせずに標準入力から1行ずつ読む
+	var readLine func() (string, error)
+	interactive := lib.IsTerminal(os.Stdin) && lib.IsTerminal(os.Stdout)
+	if interactive {
+		editor := newLineEditor("conoha> ", cmd.complete)
+		editor.history = cmd.readHistory()
+		if len(editor.history) > shellHistorySize {
+			// ファイルが大きくなりすぎないように、古いものを捨てて書き直す
+			editor.history = editor.history[len(editor.history)-shellHistorySize:]
+			cmd.rewriteHistory(editor.history)
+		}
+		readLine = editor.readLine
+
+		fmt.Println(lib.T(`Type "help" to show the commands, "exit" to quit.`))
+
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		readLine = func() (string, error) {
+			if scanner.Scan() {
+				return scanner.Text(), nil
+			} else if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		args, err := splitCommandLine(line)
+		if err != nil {
+			PrintError(os.Stderr, GetGlobalOptions().ErrorFormat, err)
+			continue
+		} else if len(args) == 0 {
+			continue
+		}
+
+		if interactive {
+			cmd.writeHistory(historyLine(line, args))
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		}
+
+		if err = cmd.execute(args); err != nil {
+			if _, ok := err.(*ShowUsageError); !ok {
+				PrintError(os.Stderr, GetGlobalOptions().ErrorFormat, err)
+			}
+		}
+
+		if cmd.loggedOut {
+			return nil
+		}
+	}
+}
+
+// logoutした場合は設定ファイルを書き込まない
+func (cmd *Shell) Shutdown() {
+	if !cmd.loggedOut {
+		cmd.Command.Shutdown()
+	}
+}
+
+// 1行分のコマンドを実行する
+func (cmd *Shell) execute(args []string) (err error) {
+	info := LookupCommand(args[0])
+	if info == nil {
+		info = LookupPlugin(args[0])
+	}
+	if info == nil {
+		return &UsageError{Err: errors.New(lib.T(`Undefined command "%s".`, args[0]))}
+	} else if info.Name == "shell" {
+		return &UsageError{Err: errors.New(lib.T("Already running in the shell."))}
+	}
+
+	// 全体のオプションのうち--output、--yes、--dry-runは行ごとに指定できる
+	opts := GetGlobalOptions()
+	saved := *opts
+	defer func() { *opts = saved }()
+
+	opts.Output, args = lib.ExtractFlag(args, "output", opts.Output)
+	if opts.Yes, args, err = lib.ExtractBoolFlag(args, "yes"); err != nil {
+		return &UsageError{Err: err}
+	}
+	opts.Yes = opts.Yes || saved.Yes
+	if opts.DryRun, args, err = lib.ExtractBoolFlag(args, "dry-run"); err != nil {
+		return &UsageError{Err: err}
+	}
+	opts.DryRun = opts.DryRun || saved.DryRun
+	if err = lib.ValidateOutputFormat(opts.Output); err != nil {
+		return &UsageError{Err: err}
+	}
+
+	// 各コマンドはos.Argsを読むので差し替える
+	argv := os.Args
+	os.Args = append([]string{argv[0]}, args...)
+	defer func() { os.Args = argv }()
+
+	lib.AddLogField("command", info.Name)
+
+	if info.RequireLogin {
+		if err = cmd.checkLogin(); err != nil {
+			return err
+		}
+	}
+
+	c := info.New()
+	defer c.Shutdown()
+	if err = c.Run(); err != nil {
+		return err
+	}
+
+	switch info.Name {
+	case "login":
+		cmd.loginChecked = time.Now()
+	case "logout":
+		cmd.loggedOut = true
+	case "profile":
+		// プロファイルを切り替えた場合は次のコマンドでログインを確認する
+		cmd.loginChecked = time.Time{}
+	}
+	return nil
+}
+
+// 前回の確認から時間が経っている場合はログイン状態を確認し、セッションが切れていたら再ログインする
+func (cmd *Shell) checkLogin() error {
+	if time.Since(cmd.loginChecked) < shellLoginCheckInterval {
+		return nil
+	}
+
+	// 通信のエラーはログインの失敗にしない
+	l := NewLogin()
+	loggedIn, err := l.LoggedIn()
+	if err != nil {
+		return err
+	}
+	if !loggedIn {
+		lib.GetLogInstance().Debugf("Session is timed out. try relogin...")
+		if loggedIn, err = l.Relogin(); err != nil {
+			return err
+		} else if !loggedIn {
+			return &AuthError{}
+		}
+	}
+
+	cmd.loginChecked = time.Now()
+	return nil
+}
+
+// タブ補完の候補
+func (cmd *Shell) complete(words []string) []string {
+	candidates := completeWords(words, NewComplete().vpsCandidates)
+
+	// シェルを終了するコマンド
+	if len(words) == 1 {
+		candidates = append(candidates, filterCandidates([]string{"exit\t" + lib.T("Exit the shell.")}, "", words[0])...)
+	}
+	return candidates
+}
+
+func (cmd *Shell) historyPath() (string, error) {
+	dir, err := cmd.config.ConfigDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, shellHistoryFile), nil
+}
+
+// 保存しておいた履歴を読む
+func (cmd *Shell) readHistory() []string {
+	history := []string{}
+
+	path, err := cmd.historyPath()
+	if err != nil {
+		return history
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return history
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		history = append(history, scanner.Text())
+	}
+	return history
+}
+
+// 履歴をファイルに追記する
+func (cmd *Shell) writeHistory(line string) {
+	path, err := cmd.historyPath()
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		lib.GetLogInstance().Debugf("could not write the history: %s", err)
+		return
+	}
+	fmt.Fprintln(file, line)
+	file.Close()
+}
+
+// 履歴のファイルを書き直す
+func (cmd *Shell) rewriteHistory(history []string) {
+	path, err := cmd.historyPath()
+	if err != nil {
+		return
+	}
+
+	if err = ioutil.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0600); err != nil {
+		lib.GetLogInstance().Debugf("could not write the history: %s", err)
+	}
+}
+
+// 履歴に保存する行
+// パスワードをファイルに平文で残さないように、パスワードのオプションの値は伏せ字にする
+func historyLine(line string, args []string) string {
+	fs := commandFlags(LookupCommand(args[0]))
+	isPassword := func(f *FlagInfo) bool {
+		return f != nil && strings.Contains(f.Name, "password")
+	}
+
+	words := make([]string, len(args))
+	copy(words, args)
+
+	redacted := false
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		if word == "--" {
+			break
+		}
+
+		if strings.HasPrefix(word, "--") {
+			// --password=VALUE と --password VALUE
+			if !isPassword(findFlagInfo(fs, word)) {
+				continue
+			}
+			if n := strings.Index(word, "="); n >= 0 {
+				words[i] = word[:n+1] + shellHistoryRedacted
+			} else if i+1 < len(words) {
+				i++
+				words[i] = shellHistoryRedacted
+			}
+			redacted = true
+
+		} else if strings.HasPrefix(word, "-") {
+			// 短いオプションはまとめて指定できる(-fP VALUE、-PVALUE)
+			for j := 1; j < len(word); j++ {
+				f := findFlagInfo(fs, "-"+word[j:j+1])
+				if f == nil || !f.Value {
+					continue
+				}
+				if isPassword(f) {
+					if j+1 < len(word) {
+						words[i] = word[:j+1] + shellHistoryRedacted
+					} else if i+1 < len(words) {
+						i++
+						words[i] = shellHistoryRedacted
+					}
+					redacted = true
+				}
+				// 値を取るオプションの後はその値になる
+				break
+			}
+		}
+	}
+
+	if !redacted {
+		return line
+	}
+
+	for i, word := range words {
+		words[i] = quoteWord(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// splitCommandLine()で一つの単語として読めるようにクォートする
+func quoteWord(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t'\"\\$") {
+		return word
+	}
+	return "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+}
+
+// コマンドラインを単語に分ける
+// 空白で区切り、シングルクォート、ダブルクォート、バックスラッシュでのエスケープに対応する
+func splitCommandLine(line string) ([]string, error) {
+	return splitWords(line, nil)
+}
+
+// expandがnilでない場合は、シングルクォートの外にある変数($NAME、${NAME})をexpandの戻り値に置き換える
+// 置き換えた値は空白を含んでいても一つの単語の一部になる
+func splitWords(line string, expand func(name string) (string, error)) ([]string, error) {
+	words := []string{}
+
+	var word []rune
+	inWord := false
+	var quote rune
+	escaped := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			word = append(word, r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '$' && expand != nil:
+			name, n := variableName(runes[i+1:])
+			if n == 0 {
+				word = append(word, r)
+				inWord = true
+				continue
+			}
+			value, err := expand(name)
+			if err != nil {
+				return nil, err
+			}
+			word = append(word, []rune(value)...)
+			inWord = true
+			i += n
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, string(word))
+				word = nil
+				inWord = false
+			}
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, &UsageError{Err: errors.New(lib.T("Unterminated quote or escape."))}
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
+
+// $の後ろの変数名(NAME、{NAME})を読んで、変数名と読んだ文字数を返す
+// 変数名でない場合は0を返す
+func variableName(runes []rune) (string, int) {
+	if len(runes) > 0 && runes[0] == '{' {
+		for i := 1; i < len(runes); i++ {
+			if runes[i] == '}' {
+				if !isVariableName(string(runes[1:i])) {
+					return "", 0
+				}
+				return string(runes[1:i]), i + 1
+			}
+		}
+		return "", 0
+	}
+
+	n := 0
+	for n < len(runes) && (runes[n] == '_' || 'a' <= runes[n] && runes[n] <= 'z' || 'A' <= runes[n] && runes[n] <= 'Z' || n > 0 && '0' <= runes[n] && runes[n] <= '9') {
+		n++
+	}
+	return string(runes[:n]), n
+}
+
+// 変数名として正しいか(英字かアンダースコアで始まり、英数字とアンダースコアからなる)
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, n := variableName([]rune(name))
+	return n == len([]rune(name))
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/lib"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		"":                                   {},
+		"  list  ":                           {"list"},
+		"stat web-01 -o json":                {"stat", "web-01", "-o", "json"},
+		`label f648 -l "web server"`:         {"label", "f648", "-l", "web server"},
+		`list --format '{{.Id}} {{.Label}}'`: {"list", "--format", "{{.Id}} {{.Label}}"},
+		`label f648 -l web\ server`:          {"label", "f648", "-l", "web server"},
+		`label f648 -l ""`:                   {"label", "f648", "-l", ""},
+	}
+	for line, want := range tests {
+		got, err := splitCommandLine(line)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q [%v]", line, got, want, err)
+		}
+	}
+
+	for _, line := range []string{`label -l "web`, `label -l 'web`, `list \`} {
+		if _, err := splitCommandLine(line); ExitCodeOf(err) != ExitCodeUsage {
+			t.Errorf("%s: should be error. [%v]", line, err)
+		}
+	}
+}
+
+func typeKeys(e *lineEditor, s string) {
+	for _, r := range s {
+		e.handleKey(lib.Key{Rune: r})
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	e := newLineEditor("> ", nil)
+	e.history = []string{"list", "stat web-01"}
+	e.histPos = len(e.history)
+
+	typeKeys(e, "stat db")
+	e.handleKey(lib.Key{Code: lib.KeyLeft})
+	e.handleKey(lib.Key{Code: lib.KeyLeft})
+	typeKeys(e, "x")
+	e.handleKey(lib.Key{Code: lib.KeyBackspace})
+	e.handleKey(lib.Key{Code: lib.KeyCtrlK})
+	if string(e.buf) != "stat " || e.pos != 5 {
+		t.Errorf("got [%s] pos=%d", string(e.buf), e.pos)
+	}
+
+	// 履歴を辿って、入力中の行に戻る
+	e.handleKey(lib.Key{Code: lib.KeyUp})
+	e.handleKey(lib.Key{Code: lib.KeyUp})
+	if string(e.buf) != "list" {
+		t.Errorf("should show the history. got [%s]", string(e.buf))
+	}
+	e.handleKey(lib.Key{Code: lib.KeyDown})
+	e.handleKey(lib.Key{Code: lib.KeyDown})
+	if string(e.buf) != "stat " {
+		t.Errorf("should restore the editing line. got [%s]", string(e.buf))
+	}
+
+	e.handleKey(lib.Key{Code: lib.KeyCtrlW})
+	if string(e.buf) != "" {
+		t.Errorf("Ctrl-W should delete the word. got [%s]", string(e.buf))
+	}
+	if _, err := e.handleKey(lib.Key{Code: lib.KeyCtrlD}); err == nil {
+		t.Errorf("Ctrl-D on the empty line should be EOF.")
+	}
+}
+
+func TestLineEditorComplete(t *testing.T) {
+	vps := func() []string {
+		return []string{"f648a6646b7e7d91\tweb-01", "f6c1d7e05fd3f3c6\tdb-01"}
+	}
+	e := newLineEditor("> ", func(words []string) []string { return completeWords(words, vps) })
+
+	// 候補が一つの場合は空白まで補完する
+	typeKeys(e, "pow")
+	e.handleKey(lib.Key{Code: lib.KeyTab})
+	if string(e.buf) != "power " {
+		t.Errorf("got [%s]", string(e.buf))
+	}
+
+	// 複数の場合は共通部分まで補完し、それ以上は一覧を表示する
+	typeKeys(e, "f")
+	e.handleKey(lib.Key{Code: lib.KeyTab})
+	if string(e.buf) != "power f6" || len(e.listing) != 0 {
+		t.Errorf("got [%s] %q", string(e.buf), e.listing)
+	}
+	e.handleKey(lib.Key{Code: lib.KeyTab})
+	if len(e.listing) != 2 {
+		t.Errorf("should list the candidates. %q", e.listing)
+	}
+}
+
+func TestHistoryLine(t *testing.T) {
+	tests := map[string]string{
+		"list -o json":                      "list -o json",
+		"login -a C12345678 -p secret":      "login -a C12345678 -p ********",
+		"login --password=secret":           "login --password=********",
+		"login --password 'my secret'":      "login --password ********",
+		"add -t basic -P secret -i centos":  "add -t basic -P ******** -i centos",
+		"add -Psecret":                      "add -P********",
+		"add -fP secret":                    "add -fP ********",
+		`label f648 -l "web server" -p x`:   `label f648 -l "web server" -p x`,
+		"profile add staging -a C2 -p x":    "profile add staging -a C2 -p ********",
+		`login -a "C1 2" --password "it's"`: `login -a 'C1 2' --password ********`,
+	}
+	for line, want := range tests {
+		args, _ := splitCommandLine(line)
+		if got := historyLine(line, args); got != want {
+			t.Errorf("%s: got %q, want %q", line, got, want)
+		}
+	}
+}
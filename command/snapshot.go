@@ -0,0 +1,320 @@
+package command
+
+// 全VPSの詳細情報をスナップショットとしてファイルに保存する
+// スナップショットは設定ディレクトリの snapshots/{アカウント}/ 以下に保存される
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// スナップショットファイルのフォーマットのバージョン
+// フォーマットを変更した場合はインクリメントすること
+const SnapshotVersion = 1
+
+type Snapshot struct {
+	Version   int
+	Name      string
+	Account   string
+	CreatedAt time.Time
+	Servers   []*Vm
+}
+
+// スナップショットを保存するディレクトリを返す
+func snapshotDir(config *lib.Config) (string, error) {
+	if config.Account == "" {
+		return "", errors.New(lib.T("Account is not set. Please log in."))
+	}
+
+	dir, err := config.ConfigDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "snapshots", filepath.Base(config.Account))
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// スナップショット名からファイルのパスを返す
+func snapshotPath(config *lib.Config, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", errors.New(lib.T(`Invalid snapshot name "%s".`, name))
+	}
+
+	dir, err := snapshotDir(config)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// スナップショットを読み込む
+func LoadSnapshot(config *lib.Config, name string) (*Snapshot, error) {
+	path, err := snapshotPath(config, name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &NotFoundError{lib.T(`Snapshot "%s" not found.`, name)}
+	}
+	defer file.Close()
+
+	s := &Snapshot{}
+	if err = json.NewDecoder(file).Decode(s); err != nil {
+		return nil, err
+	}
+
+	if s.Version > SnapshotVersion {
+		return nil, errors.New(lib.T(`Snapshot "%s" is created by a newer version(format version %d).`, name, s.Version))
+	}
+	return s, nil
+}
+
+// スナップショットを保存する
+func (s *Snapshot) Save(config *lib.Config) (path string, err error) {
+	path, err = snapshotPath(config, s.Name)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	return path, err
+}
+
+// --------------------------------
+
+type VpsSnapshot struct {
+	action string
+	name   string
+	*Vps
+}
+
+func NewVpsSnapshot() *VpsSnapshot {
+	return &VpsSnapshot{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *VpsSnapshot) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	cmd.addOutputFlag(fs)
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if err := cmd.validateOutputFlag(); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	args := fs.Args()
+	if len(args) < 2 {
+		fs.Usage()
+		return errors.New(lib.T("Not enough arguments."))
+	}
+
+	cmd.action = args[1]
+	if len(args) > 2 {
+		cmd.name = args[2]
+	}
+
+	switch cmd.action {
+	case "save":
+		if cmd.name == "" {
+			cmd.name = time.Now().Format("20060102-150405")
+		}
+	case "remove":
+		if cmd.name == "" {
+			fs.Usage()
+			return errors.New(lib.T("Not enough arguments."))
+		}
+	case "list":
+	default:
+		fs.Usage()
+		return errors.New(lib.T(`Undefined action "%s".`, cmd.action))
+	}
+
+	return nil
+}
+
+func (cmd *VpsSnapshot) Usage() {
+	printUsage("snapshot")
+}
+
+func (cmd *VpsSnapshot) Run() error {
+	log := lib.GetLogInstance()
+
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	switch cmd.action {
+	case "save":
+		// 詳細を取得できなかったVPSがあっても、取得できた分は保存してからエラーを返す
+		s, takeErr := cmd.Take(cmd.name)
+		if s == nil {
+			return takeErr
+		}
+
+		path, err := s.Save(cmd.config)
+		if err != nil {
+			return err
+		}
+		log.Info(lib.T(`Snapshot "%s" is saved to "%s"(%d VPS).`, s.Name, path, len(s.Servers)))
+		return takeErr
+
+	case "list":
+		return cmd.list()
+
+	case "remove":
+		path, err := snapshotPath(cmd.config, cmd.name)
+		if err != nil {
+			return err
+		}
+
+		if err = os.Remove(path); err != nil {
+			return &NotFoundError{lib.T(`Snapshot "%s" not found.`, cmd.name)}
+		}
+		log.Info(lib.T(`Snapshot "%s" is removed.`, cmd.name))
+	}
+	return nil
+}
+
+// 全VPSの詳細情報を取得してスナップショットを作成する
+// キャッシュは使わない(取得した内容でキャッシュは更新する)
+// 詳細を取得できなかったVPSがある場合は、一覧の情報だけのVPSを含むスナップショットとエラーを返す
+func (cmd *VpsSnapshot) Take(name string) (*Snapshot, error) {
+	vpsList := &VpsList{Vps: cmd.withRefresh()}
+	servers, err := vpsList.List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for i, err := range vpsList.Details(servers, DefaultParallel) {
+		if err != nil {
+			lib.GetLogInstance().Warn(lib.T("Could not get the details of VPS(id=%s): %s", servers[i].Id, err))
+			failed++
+		}
+	}
+
+	s := &Snapshot{
+		Version:   SnapshotVersion,
+		Name:      name,
+		Account:   cmd.config.Account,
+		CreatedAt: time.Now(),
+		Servers:   servers,
+	}
+
+	if failed > 0 {
+		return s, errors.New(lib.T("Could not get the details of %d VPS.", failed))
+	}
+	return s, nil
+}
+
+// スナップショットの一覧を表示する
+func (cmd *VpsSnapshot) list() error {
+	dir, err := snapshotDir(cmd.config)
+	if err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	snapshots := []*Snapshot{}
+	for _, fi := range files {
+		if !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+
+		s, err := LoadSnapshot(cmd.config, strings.TrimSuffix(fi.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	sort.Sort(snapshotsByDate(snapshots))
+
+	if cmd.output != lib.OutputTable || cmd.template != nil {
+		type summary struct {
+			Name      string    `json:"name" yaml:"name"`
+			CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+			Vps       int       `json:"vps" yaml:"vps"`
+		}
+
+		data := []*summary{}
+		items := []interface{}{}
+		table := &lib.Table{
+			Header: []string{"name", "createdAt", "vps"},
+		}
+		for _, s := range snapshots {
+			sm := &summary{s.Name, s.CreatedAt, len(s.Servers)}
+			data = append(data, sm)
+			items = append(items, sm)
+			table.Rows = append(table.Rows, []string{s.Name, formatDate(s.CreatedAt), fmt.Sprint(len(s.Servers))})
+		}
+
+		if cmd.template != nil {
+			return lib.RenderTemplate(os.Stdout, cmd.template, items...)
+		}
+		return lib.Render(os.Stdout, cmd.output, data, table)
+	}
+
+	table := &lib.Table{
+		Header: []string{lib.T("Name"), lib.T("CreatedAt"), lib.T("VPS")},
+	}
+	for _, s := range snapshots {
+		table.Rows = append(table.Rows, []string{s.Name, s.CreatedAt.Format("2006/01/02 15:04 MST"), fmt.Sprint(len(s.Servers))})
+	}
+
+	return lib.RenderTable(os.Stdout, table, &lib.TableOptions{
+		MaxWidth: lib.TerminalWidth(os.Stdout),
+		Color:    lib.UseColor(lib.ColorAuto, os.Stdout),
+	})
+}
+
+type snapshotsByDate []*Snapshot
+
+func (s snapshotsByDate) Len() int           { return len(s) }
+func (s snapshotsByDate) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s snapshotsByDate) Less(i, j int) bool { return s[i].CreatedAt.Before(s[j].CreatedAt) }
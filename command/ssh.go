@@ -2,9 +2,7 @@ package command
 
 import (
 	"errors"
-	"fmt"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"os"
 	"os/exec"
 	"strings"
@@ -33,24 +31,31 @@ func NewSsh() *Ssh {
 func (cmd *Ssh) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
 	// pflagsはparse()すると設定していないフラグが全てエラーになってしまう。
 	// 仕方ないので、ssh コマンドにオプションを渡せるようにするため、自前でパースする。
 	options := []string{}
 	for i := 2; i < len(os.Args); i++ {
-		// 最初の引数は - で開始してない場合はVPS-IDとみなす
-		if i == 2 && !strings.HasPrefix(os.Args[i], "-") {
+		// sshのオプションより前にある、- で開始してない最初の引数をVPS-IDとみなす
+		// (conoha ssh -u user ID のようにconohaのオプションの後でもよい)
+		if cmd.vmId == "" && len(options) == 0 && !strings.HasPrefix(os.Args[i], "-") {
 			cmd.vmId = os.Args[i]
 			continue
 		}
 
-		if os.Args[i] == "-h" {
+		if os.Args[i] == "-h" || os.Args[i] == "--help" {
 			help = true
 		} else if os.Args[i] == "-u" {
+			if i+1 >= len(os.Args) {
+				return errors.New(lib.T("Not enough arguments."))
+			}
 			cmd.sshUser = os.Args[i+1]
 			i++
+		} else if os.Args[i] == "--refresh" {
+			cmd.refresh = true
+		} else if os.Args[i] == "--no-cache" {
+			cmd.noCache = true
 		} else {
 			options = append(options, os.Args[i])
 		}
@@ -61,6 +66,8 @@ func (cmd *Ssh) parseFlag() error {
 		return &ShowUsageError{}
 	}
 
+	cmd.applyCacheFlags()
+
 	if cmd.vmId == "" {
 		vm, err := cmd.Vps.vpsSelectMenu()
 		if err != nil {
@@ -78,21 +85,7 @@ func (cmd *Ssh) parseFlag() error {
 }
 
 func (cmd *Ssh) Usage() {
-	fmt.Println(`Usage: conoha ssh <VPS-ID> [OPTIONS ...]
-
-DESCRIPTION
-    Login to VPS via SSH.
-    There needs to be installed SSH client and all of option parameters will be passed into SSH command.
-
-    It may not work on Windows.
-
-<VPS-ID> (Optional)VPS-ID to get the stats. It may be confirmed by LIST subcommand.
-         If not set, It will be selected from the list menu.
-
-OPTIONS
-    -u: --user:     SSH username.
-    -h: --help:     Show usage.      
-`)
+	printUsage("ssh")
 }
 
 func (cmd *Ssh) Run() error {
@@ -101,23 +94,26 @@ func (cmd *Ssh) Run() error {
 
 	var err error
 	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+	if cmd.vmId, err = cmd.resolveVmId(cmd.vmId); err != nil {
 		return err
 	}
+	lib.AddLogField("vps_id", cmd.vmId)
 
-	vpsList := NewVpsList()
+	vpsList := &VpsList{Vps: cmd.Vps}
 	vm := vpsList.Vm(cmd.vmId)
 	if vm == nil {
-		msg := fmt.Sprintf("VPS not found(id=%s).", cmd.vmId)
-		return errors.New(msg)
+		return &VpsNotFoundError{VmId: cmd.vmId}
 	}
 
 	// Windwsプランの場合は何もしない
 	if strings.Index(vm.Plan, "Windows") >= 0 {
-		log.Infof("ID=%s. Windows plan is not supported ssh connect.", vm.Id)
+		log.Info(lib.T("ID=%s. Windows plan is not supported ssh connect.", vm.Id))
 		return nil
 	}
 
-	vpsStat := NewVpsStat()
+	vpsStat := &VpsStat{Vps: cmd.Vps}
 	stat, err := vpsStat.Stat(vm.Id)
 	if err != nil {
 		return err
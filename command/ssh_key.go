@@ -1,12 +1,9 @@
 package command
 
 import (
-	"errors"
-	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -33,12 +30,11 @@ func NewSshKey() *SshKey {
 func (cmd *SshKey) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.StringVarP(&cmd.destPath, "path", "f", "", ``)
-	fs.IntVarP(&cmd.sshKeyNo, "sshkey-no", "s", 1, ``)
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.destPath, "path", "f", "", "")
+	fs.IntVarP(&cmd.sshKeyNo, "sshkey-no", "s", 1, "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
@@ -59,21 +55,7 @@ func (cmd *SshKey) parseFlag() error {
 }
 
 func (cd *SshKey) Usage() {
-	fmt.Println(`Usage: conoha ssh-key <file> [OPTIONS]
-
-DESCRIPTION
-    Download and store SSH Private key.
-
-
-OPTIONS
-    -f: --file:       Local filename the private key is stored.
-                      Default is "conoha-{AccountID}.key"
-
-    -s: --sshkey-no:  SSH Key number. Default is 1.
-                      If the number of keys one, It wil be ignored.
-
-    -h: --help:       Show usage.      
-`)
+	printUsage("ssh-key")
 }
 
 func (cmd *SshKey) Run() error {
@@ -81,12 +63,12 @@ func (cmd *SshKey) Run() error {
 
 	var err error
 	if err = cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
 	}
 
 	err = cmd.DownloadSshKey(cmd.destPath)
 	if err == nil {
-		log.Infof(`Download is complete. A private key is stored in "%s".`, cmd.destPath)
+		log.Info(lib.T(`Download is complete. A private key is stored in "%s".`, cmd.destPath))
 		return nil
 	} else {
 		return err
@@ -184,7 +166,7 @@ func (r *sshDownloadFormResult) Populate(resp *http.Response, doc *goquery.Docum
 	}
 
 	if r.SshKeyName == "" {
-		return errors.New("SSH Key not found.")
+		return &NotFoundError{lib.T("SSH Key not found.")}
 	}
 
 	return nil
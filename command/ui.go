@@ -0,0 +1,675 @@
+package command
+
+// 端末のダッシュボード
+// conoha ui でVPSの一覧と選択したVPSの詳細を全画面に表示し、キー操作で電源操作、ラベルの変更、SSH接続をする
+// 一覧とServerStatusはバックグラウンドで定期的に取得する
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"github.com/mattn/go-runewidth"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+type VpsUi struct {
+	// 一覧を更新する間隔
+	interval time.Duration
+
+	*Vps
+}
+
+func NewVpsUi() *VpsUi {
+	return &VpsUi{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *VpsUi) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.DurationVarP(&cmd.interval, "interval", "i", 10*time.Second, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if cmd.interval <= 0 {
+		return errors.New(lib.T("Interval should be greater than zero."))
+	}
+
+	if !lib.IsTerminal(os.Stdin) || !lib.IsTerminal(os.Stdout) {
+		return errors.New(lib.T("This command requires a terminal."))
+	}
+	return nil
+}
+
+func (cmd *VpsUi) Usage() {
+	printUsage("ui")
+}
+
+func (cmd *VpsUi) Run() error {
+	if err := cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	// 表示する情報は常に最新のものを取得する
+	cmd.refresh = true
+
+	return newDashboard(cmd.Vps, cmd.interval).run()
+}
+
+// ダッシュボードの入力モード
+const (
+	uiNormal  = iota // キー操作
+	uiConfirm        // y/nの確認
+	uiInput          // 文字列の入力
+)
+
+// キー操作(ヘルプの行に表示する)
+var uiKeys = [][]string{
+	{"↑↓", "Select"},
+	{"b", "Boot"},
+	{"r", "Reboot"},
+	{"s", "Shutdown"},
+	{"f", "Stop"},
+	{"l", "Label"},
+	{"Enter", "SSH"},
+	{"c", "Copy IP"},
+	{"u", "Update"},
+	{"q", "Quit"},
+}
+
+type dashboard struct {
+	interval time.Duration
+
+	// バックグラウンドの処理はfork()したものを使う
+	vps *Vps
+
+	servers []*Vm
+	updated time.Time
+	loading bool
+
+	// 取得したVPSの詳細(VPS-IDがキー)
+	details  map[string]*Vm
+	fetching map[string]bool
+
+	// 選択しているVPSと、一覧の表示を開始する位置
+	cursor int
+	offset int
+
+	// 最下部に表示するメッセージ
+	message string
+
+	// 確認と入力
+	mode      int
+	prompt    string
+	input     []rune
+	onConfirm func()
+	onInput   func(s string)
+
+	// バックグラウンドの処理の結果はeventsに送り、メインループで状態に反映する
+	// runningは結果をまだ反映していない処理の数
+	events  chan func(d *dashboard)
+	running int
+
+	// キー入力。一つ処理するごとにnextに送る
+	keys chan lib.Key
+	next chan struct{}
+
+	// rawモードを戻す関数
+	restore func()
+
+	color bool
+	quit  bool
+}
+
+func newDashboard(vps *Vps, interval time.Duration) *dashboard {
+	return &dashboard{
+		interval: interval,
+		vps:      vps,
+		details:  map[string]*Vm{},
+		fetching: map[string]bool{},
+		events:   make(chan func(d *dashboard), 16),
+		keys:     make(chan lib.Key),
+		next:     make(chan struct{}),
+		color:    lib.UseColor(lib.ColorAuto, os.Stdout),
+	}
+}
+
+func (d *dashboard) run() (err error) {
+	if err = d.enterScreen(); err != nil {
+		return err
+	}
+	defer d.leaveScreen()
+
+	// ログは画面が崩れるので表示しない
+	log := lib.GetLogInstance()
+	out := log.Out
+	log.Out = ioutil.Discard
+	defer func() { log.Out = out }()
+
+	go d.readKeys()
+	d.refresh()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	// 端末のサイズの変更に追従するため定期的に再描画する
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	for !d.quit {
+		d.draw()
+
+		select {
+		case key := <-d.keys:
+			d.handleKey(key)
+			d.next <- struct{}{}
+		case fn := <-d.events:
+			fn(d)
+		case <-ticker.C:
+			d.refresh()
+		case <-redraw.C:
+		}
+	}
+	return nil
+}
+
+// 代替画面に切り替えて、端末をrawモードにする
+func (d *dashboard) enterScreen() (err error) {
+	if d.restore, err = lib.MakeRaw(os.Stdin); err != nil {
+		return err
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+func (d *dashboard) leaveScreen() {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	d.restore()
+}
+
+// 標準入力からキーを読んでkeysに送る
+func (d *dashboard) readKeys() {
+	for {
+		keys, err := lib.ReadKeys(os.Stdin)
+		if err != nil {
+			d.events <- func(d *dashboard) { d.quit = true }
+			return
+		}
+		for _, key := range keys {
+			d.keys <- key
+			<-d.next
+		}
+	}
+}
+
+// goroutineで使うVpsを作る
+// Browserは並行して使えないので、セッションを共有したForkを使う
+func (d *dashboard) fork() *Vps {
+	v := *d.vps
+	c := *v.Command
+	c.browser = c.browser.Fork()
+	v.Command = &c
+	return &v
+}
+
+// fnをバックグラウンドで実行する
+// fnの戻り値はメインループで呼ばれるので、状態の変更はその中で行うこと
+func (d *dashboard) background(fn func() func(d *dashboard)) {
+	d.running++
+	go func() {
+		result := fn()
+		d.events <- func(d *dashboard) {
+			d.running--
+			result(d)
+		}
+	}()
+}
+
+// VPSの一覧とServerStatusをバックグラウンドで取得する
+func (d *dashboard) refresh() {
+	if d.loading {
+		return
+	}
+	d.loading = true
+
+	vps := d.fork()
+	d.background(func() func(d *dashboard) {
+		servers, err := (&VpsList{Vps: vps}).List(true)
+		return func(d *dashboard) {
+			d.loading = false
+			if err != nil {
+				d.message = err.Error()
+				return
+			}
+			d.setServers(servers)
+		}
+	})
+}
+
+func (d *dashboard) setServers(servers []*Vm) {
+	// 選択しているVPSは一覧が変わっても選択したままにする
+	selected := d.selected()
+	d.servers = servers
+	d.updated = time.Now()
+	d.cursor = 0
+	if selected != nil {
+		for i, vm := range servers {
+			if vm.Id == selected.Id {
+				d.cursor = i
+			}
+		}
+	}
+	d.moveCursor(0)
+
+	// 詳細のServerStatusを一覧に合わせる
+	for _, vm := range servers {
+		if detail := d.details[vm.Id]; detail != nil {
+			detail.ServerStatus = vm.ServerStatus
+		}
+	}
+	d.loadDetail()
+}
+
+// 選択しているVPS
+func (d *dashboard) selected() *Vm {
+	if d.cursor < 0 || d.cursor >= len(d.servers) {
+		return nil
+	}
+	return d.servers[d.cursor]
+}
+
+// 選択しているVPSの詳細をバックグラウンドで取得する
+func (d *dashboard) loadDetail() {
+	vm := d.selected()
+	if vm == nil || vm.Id == "" || d.details[vm.Id] != nil || d.fetching[vm.Id] {
+		return
+	}
+	d.fetching[vm.Id] = true
+
+	id := vm.Id
+	vps := d.fork()
+	d.background(func() func(d *dashboard) {
+		detail, err := (&VpsStat{Vps: vps}).Stat(id)
+		return func(d *dashboard) {
+			delete(d.fetching, id)
+			if err != nil {
+				d.message = err.Error()
+				return
+			}
+			d.details[id] = detail
+		}
+	})
+}
+
+func (d *dashboard) moveCursor(n int) {
+	d.cursor += n
+	if d.cursor >= len(d.servers) {
+		d.cursor = len(d.servers) - 1
+	}
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+func (d *dashboard) handleKey(key lib.Key) {
+	switch d.mode {
+	case uiConfirm:
+		d.mode = uiNormal
+		if key.Rune == 'y' || key.Rune == 'Y' {
+			d.onConfirm()
+		} else {
+			d.message = lib.T("Canceled.")
+		}
+		return
+
+	case uiInput:
+		switch key.Code {
+		case lib.KeyEnter:
+			d.mode = uiNormal
+			d.onInput(string(d.input))
+		case lib.KeyEsc, lib.KeyCtrlC:
+			d.mode = uiNormal
+			d.message = lib.T("Canceled.")
+		case lib.KeyBackspace:
+			if len(d.input) > 0 {
+				d.input = d.input[:len(d.input)-1]
+			}
+		case lib.KeyCtrlU:
+			d.input = []rune{}
+		case lib.KeyNone:
+			d.input = append(d.input, key.Rune)
+		}
+		return
+	}
+
+	d.message = ""
+
+	switch key.Code {
+	case lib.KeyUp:
+		d.moveCursor(-1)
+		d.loadDetail()
+		return
+	case lib.KeyDown:
+		d.moveCursor(1)
+		d.loadDetail()
+		return
+	case lib.KeyEnter:
+		d.ssh()
+		return
+	case lib.KeyCtrlC, lib.KeyEsc:
+		d.quit = true
+		return
+	}
+
+	switch key.Rune {
+	case 'k':
+		d.moveCursor(-1)
+		d.loadDetail()
+	case 'j':
+		d.moveCursor(1)
+		d.loadDetail()
+	case 'b':
+		d.power(BOOT)
+	case 'r':
+		d.power(REBOOT)
+	case 's':
+		d.power(SHUTDOWN)
+	case 'f':
+		d.power(STOP)
+	case 'l':
+		d.label()
+	case 'c':
+		d.copyAddress()
+	case 'u':
+		if vm := d.selected(); vm != nil {
+			delete(d.details, vm.Id)
+		}
+		d.refresh()
+	case 'q':
+		d.quit = true
+	}
+}
+
+// y/nで確認してからfnを実行する
+func (d *dashboard) confirm(prompt string, fn func()) {
+	d.mode = uiConfirm
+	d.prompt = prompt + " [y/N]"
+	d.onConfirm = fn
+}
+
+// 電源操作のコマンドを送信する
+func (d *dashboard) power(command string) {
+	vm := d.selected()
+	if vm == nil {
+		return
+	}
+
+	d.confirm(lib.T(`Send "%s" command to VPS(Label=%s). Are you sure?`, command, vm.Label), func() {
+		d.message = lib.T(`Sending "%s" command...`, command)
+		power := &VpsPower{Vps: d.fork(), forceSend: true}
+		d.background(func() func(d *dashboard) {
+			record := power.newAuditRecord(lib.AuditPower, vm.Id)
+			record.Params = map[string]string{"command": command, "force-send": "false"}
+			record.Confirmation = lib.AuditConfirmPrompt
+
+			err := power.SendCommand(vm.Id, command)
+			power.audit(record, err)
+			return func(d *dashboard) {
+				if err != nil {
+					d.message = err.Error()
+					return
+				}
+				d.message = lib.T(`"%s" command was sent to VPS(id=%s).`, command, vm.Id)
+				d.refresh()
+			}
+		})
+	})
+}
+
+// ラベルを入力して変更する
+func (d *dashboard) label() {
+	vm := d.selected()
+	if vm == nil {
+		return
+	}
+
+	d.mode = uiInput
+	d.prompt = lib.T("New label: ")
+	d.input = []rune(vm.Label)
+	d.onInput = func(label string) {
+		if label == "" || label == vm.Label {
+			d.message = lib.T("Canceled.")
+			return
+		}
+		if len(label) > 20 {
+			d.message = lib.T("Label is too long(should be 20 characters or less). ")
+			return
+		}
+
+		cmd := &VpsLabel{Vps: d.fork(), label: label}
+		d.background(func() func(d *dashboard) {
+			record := cmd.newAuditRecord(lib.AuditLabel, vm.Id)
+			record.Params = map[string]string{"label": label}
+
+			err := cmd.Change(vm.Id, label)
+			cmd.audit(record, err)
+			return func(d *dashboard) {
+				if err != nil {
+					d.message = err.Error()
+					return
+				}
+				d.message = lib.T(`VPS Label was changed to "%s"`, label)
+				delete(d.details, vm.Id)
+				d.refresh()
+			}
+		})
+	}
+}
+
+// 選択しているVPSの詳細(IPアドレスが必要な操作で使う)
+func (d *dashboard) selectedDetail() (*Vm, error) {
+	vm := d.selected()
+	if vm == nil {
+		return nil, &VpsNotFoundError{}
+	}
+	if detail := d.details[vm.Id]; detail != nil {
+		return detail, nil
+	}
+	return nil, errors.New(lib.T("The details of VPS are not loaded yet."))
+}
+
+// 選択しているVPSにSSHで接続する
+// SSHが終了するまでダッシュボードを中断する
+func (d *dashboard) ssh() {
+	vm, err := d.selectedDetail()
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+	if strings.Index(vm.Plan, "Windows") >= 0 {
+		d.message = lib.T("ID=%s. Windows plan is not supported ssh connect.", vm.Id)
+		return
+	}
+
+	d.leaveScreen()
+	(&Ssh{Vps: d.vps}).Connect(vm.IPv4, "root", nil)
+	if err := d.enterScreen(); err != nil {
+		d.quit = true
+	}
+}
+
+// 選択しているVPSのIPアドレスをクリップボードにコピーする
+// 端末のOSC 52に対応している必要がある
+func (d *dashboard) copyAddress() {
+	vm, err := d.selectedDetail()
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	fmt.Printf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(vm.IPv4)))
+	d.message = lib.T("Copied %s to the clipboard.", vm.IPv4)
+}
+
+func (d *dashboard) draw() {
+	width, height := lib.TerminalSize(os.Stdout)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("\x1b[H")
+	for i, line := range d.render(width, height) {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString(line + "\x1b[K")
+	}
+	buf.WriteString("\x1b[J")
+	os.Stdout.Write(buf.Bytes())
+}
+
+// 画面の各行を作る
+func (d *dashboard) render(width int, height int) []string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 10 {
+		height = 10
+	}
+
+	lines := []string{}
+	add := func(line string, attr string) {
+		line = runewidth.Truncate(line, width, "")
+		if attr != "" && d.color {
+			line = "\x1b[" + attr + "m" + lib.PadRight(line, width) + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+
+	// ヘッダ
+	header := fmt.Sprintf(" conoha ui  %s", lib.T("%d VPS", len(d.servers)))
+	if !d.updated.IsZero() {
+		header += "  " + lib.T("Updated at %s", d.updated.Format("15:04:05"))
+	}
+	if d.loading {
+		header += "  " + lib.T("Loading...")
+	}
+	add(header, "7")
+
+	// 詳細
+	var fields [][]string
+	if vm := d.selected(); vm != nil {
+		if detail := d.details[vm.Id]; detail != nil {
+			fields = vmStatFields(detail, true)
+		} else {
+			fields = [][]string{{"VPS ID", vm.Id}, {"Label", vm.Label}, {"Plan", vm.Plan}, {"", lib.T("Loading...")}}
+		}
+	}
+
+	// 幅が広い場合は詳細を2列で表示する
+	columns := 1
+	if width >= 100 {
+		columns = 2
+	}
+	detailRows := (len(fields) + columns - 1) / columns
+
+	// 一覧は残りの行に表示する(ヘッダ、一覧の見出し、区切り、メッセージ、ヘルプの5行を除く)
+	listRows := height - 5 - detailRows
+	if listRows > len(d.servers) {
+		listRows = len(d.servers)
+	}
+	if listRows < 3 {
+		listRows = 3
+	}
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	}
+	if d.cursor >= d.offset+listRows {
+		d.offset = d.cursor - listRows + 1
+	}
+
+	// 一覧
+	add("  "+uiListRow(lib.T("VPS ID"), lib.T("Label"), lib.T("Server Status"), lib.T("Plan")), "1")
+	for i := d.offset; i < d.offset+listRows; i++ {
+		if i >= len(d.servers) {
+			add("", "")
+			continue
+		}
+		vm := d.servers[i]
+
+		marker := "  "
+		if i == d.cursor {
+			marker = "> "
+		}
+		status := vm.ServerStatus.String()
+		line := marker + uiListRow(vm.Id, vm.Label, status, vm.Plan)
+		line = runewidth.Truncate(line, width, "")
+
+		switch {
+		case !d.color:
+		case i == d.cursor:
+			line = "\x1b[1;4m" + line + "\x1b[0m"
+		default:
+			// ServerStatusに色を付ける
+			if n := strings.Index(line, status); n >= 0 && len(vm.Id) > 0 {
+				line = line[:n] + lib.Colorize(status, vm.ServerStatus.Color()) + line[n+len(status):]
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	// 詳細
+	add(strings.Repeat("─", width), "")
+	rows := height - len(lines) - 2
+	for row := 0; row < rows; row++ {
+		line := ""
+		for col := 0; col < columns; col++ {
+			i := col*detailRows + row
+			if row >= detailRows || i >= len(fields) {
+				continue
+			}
+			cell := " " + lib.PadRight(lib.T(fields[i][0]), 20) + " " + fields[i][1]
+			line += lib.PadRight(runewidth.Truncate(cell, width/columns-1, ""), width/columns)
+		}
+		add(strings.TrimRight(line, " "), "")
+	}
+
+	// メッセージと確認、入力
+	switch d.mode {
+	case uiConfirm:
+		add(" "+d.prompt, "1")
+	case uiInput:
+		add(" "+d.prompt+string(d.input)+"_", "1")
+	default:
+		add(" "+d.message, "")
+	}
+
+	// ヘルプ
+	help := []string{}
+	for _, k := range uiKeys {
+		help = append(help, k[0]+" "+lib.T(k[1]))
+	}
+	add(" "+strings.Join(help, "  "), "7")
+
+	return lines
+}
+
+// 一覧の行
+func uiListRow(id string, label string, status string, plan string) string {
+	return lib.PadRight(id, 18) + lib.PadRight(label, 22) + lib.PadRight(status, 16) + plan
+}
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"errors"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDashboard() *dashboard {
+	d := newDashboard(&Vps{Command: &Command{browser: cpanel.NewBrowser()}}, time.Minute)
+	d.servers = []*Vm{
+		{Id: "f648a6646b7e7d91", Label: "web", Plan: "1GB Memory", ServerStatus: StatusRunning},
+		{Id: "a2ae45355615d641", Label: "db", Plan: "4GB Memory", ServerStatus: StatusOffline},
+	}
+	// 詳細を取得しに行かないように、取得済みにしておく
+	for _, vm := range d.servers {
+		d.details[vm.Id] = vm
+	}
+	return d
+}
+
+func TestDashboardRender(t *testing.T) {
+	d := newTestDashboard()
+	d.cursor = 1
+
+	lines := d.render(80, 24)
+	if len(lines) != 24 {
+		t.Errorf("should render 24 lines. got %d", len(lines))
+	}
+
+	screen := strings.Join(lines, "\n")
+	want := []string{
+		lib.T("%d VPS", 2),
+		"  f648a6646b7e7d91",
+		"> a2ae45355615d641",
+		"db",
+		lib.T("Select"),
+	}
+	for _, s := range want {
+		if !strings.Contains(screen, s) {
+			t.Errorf("screen should contain [%s]\n%s", s, screen)
+		}
+	}
+}
+
+func TestDashboardHandleKey(t *testing.T) {
+	d := newTestDashboard()
+
+	d.handleKey(lib.Key{Rune: 'j'})
+	d.handleKey(lib.Key{Rune: 'j'})
+	if d.cursor != 1 {
+		t.Errorf("cursor should stop at the last VPS. got %d", d.cursor)
+	}
+	d.handleKey(lib.Key{Code: lib.KeyUp})
+	if d.cursor != 0 {
+		t.Errorf("cursor should move up. got %d", d.cursor)
+	}
+
+	// 確認でy以外を押すとキャンセルする
+	d.handleKey(lib.Key{Rune: 'b'})
+	if d.mode != uiConfirm {
+		t.Errorf("should ask the confirmation.")
+	}
+	d.handleKey(lib.Key{Rune: 'n'})
+	if d.mode != uiNormal || d.message != lib.T("Canceled.") {
+		t.Errorf("should be canceled. [%s]", d.message)
+	}
+
+	// ラベルの入力
+	d.handleKey(lib.Key{Rune: 'l'})
+	if d.mode != uiInput {
+		t.Errorf("should ask the label.")
+	}
+	d.handleKey(lib.Key{Rune: 'x'})
+	d.handleKey(lib.Key{Code: lib.KeyBackspace})
+	d.handleKey(lib.Key{Code: lib.KeyEsc})
+	if d.mode != uiNormal || d.quit {
+		t.Errorf("Esc should cancel the input, not quit.")
+	}
+
+	d.handleKey(lib.Key{Rune: 'q'})
+	if !d.quit {
+		t.Errorf("should quit.")
+	}
+}
+
+// コントロールパネルの代わりに応答を返す
+// 最初のfail回のリクエストと、failStatusの場合はステータスの取得を通信エラーにする
+type fakePanel struct {
+	fail       int32
+	failStatus bool
+}
+
+func (p *fakePanel) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&p.fail, -1) >= 0 || (p.failStatus && req.URL.Path == "/Service/VPS/GetVMStatus.aspx") {
+		return nil, errors.New("connection refused")
+	}
+
+	body := "<html></html>"
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/Service/VPS/Control/Console/"):
+		body = `<div id="subCtrlBoxNav"><span class="startData">Started:2015/01/27</span><span class="endData">Scheduled Removal Date:</span></div>` +
+			"<dl class=\"listStyle01\"><dd>Connect to: console.example.jp/</dd>\n<dd>Connect to: sftp.example.jp/</dd></dl>"
+	case req.URL.Path == "/Service/VPS/":
+		body = `<table id="gridServiceList"><tr><th>Label</th></tr>` +
+			`<tr id="tr1"><td></td><td></td><td><a href="Control/Console/f648a6646b7e7d91">web</a></td><td>In operation</td></tr>` +
+			`<tr id="tr2"><td></td><td></td><td><a href="Control/Console/a2ae45355615d641">db</a></td><td>In operation</td></tr></table>`
+	case req.URL.Path == "/Service/VPS/GetVMStatus.aspx":
+		body = `{"status_id":"1","status_name":"Running"}`
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// HTTPリクエストをfakePanelに送るようにする
+func useFakePanel(fail int32) func() {
+	transport := http.DefaultTransport
+	http.DefaultTransport = &fakePanel{fail: fail}
+	return func() { http.DefaultTransport = transport }
+}
+
+// バックグラウンドの処理を並行して実行する
+// go test -race で、共有しているBrowserやキャッシュの競合を検出する
+func TestDashboardConcurrentActions(t *testing.T) {
+	defer setupHome(t, `{"Account":"C12345678","Password":"p","Sid":"s"}`)()
+	// ロガーは起動時に作られるので、ここでも先に作っておく
+	lib.GetLogInstance()
+
+	defer useFakePanel(0)()
+
+	config := &lib.Config{}
+	config.Read()
+	config.UseProfile("")
+	defer shareConfig(config)()
+	lib.GetCacheInstance().Configure(config)
+	defer lib.GetCacheInstance().Configure(&lib.Config{})
+
+	d := newDashboard(NewVps(), time.Minute)
+	d.vps.refresh = true
+	d.servers = []*Vm{{Id: "f648a6646b7e7d91", Label: "web", ServerStatus: StatusRunning}}
+
+	d.refresh()
+	d.loadDetail()
+	d.power(REBOOT)
+	d.onConfirm()
+	d.label()
+	d.onInput("db")
+
+	// 結果を反映すると一覧と詳細を取得し直すので、すべての処理が終わるまで待つ
+	for d.running > 0 {
+		select {
+		case fn := <-d.events:
+			fn(d)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for the background actions.")
+		}
+	}
+
+	if d.details["f648a6646b7e7d91"] == nil {
+		t.Errorf("details should be loaded. [%s]", d.message)
+	}
+}
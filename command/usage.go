@@ -0,0 +1,199 @@
+package command
+
+// 使い方(-h)の表示
+// CommandInfoの定義から作る
+
+import (
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"github.com/mattn/go-runewidth"
+	"io"
+	"os"
+	"strings"
+)
+
+// 使い方の字下げ
+const usageIndent = "    "
+
+// コマンドの使い方を標準出力に表示する
+func printUsage(name string) {
+	if info := LookupCommand(name); info != nil {
+		writeUsage(os.Stdout, info)
+	}
+}
+
+// コマンドの使い方を出力する
+func writeUsage(w io.Writer, info *CommandInfo) {
+	fmt.Fprintf(w, "%s %s\n\n", lib.T("Usage:"), commandSynopsis(info))
+
+	fmt.Fprintln(w, lib.T("DESCRIPTION"))
+	for _, line := range translateLines(info.Description) {
+		fmt.Fprintln(w, usageIndent+line)
+	}
+	fmt.Fprintln(w)
+
+	if len(info.Args) > 0 {
+		fmt.Fprintln(w, lib.T("ARGUMENTS"))
+		terms := [][]string{}
+		for _, arg := range info.Args {
+			terms = append(terms, []string{arg.Name, lib.T(arg.Description)})
+		}
+		writeTerms(w, terms)
+		fmt.Fprintln(w)
+	}
+
+	// conohaの場合はコマンドの一覧と全体のオプション
+	title := "OPTIONS"
+	if info == conohaInfo {
+		fmt.Fprintln(w, lib.T("COMMANDS"))
+		terms := [][]string{}
+		for _, c := range visibleCommands() {
+			name := c.Name
+			if len(c.Aliases) > 0 {
+				name += " (" + strings.Join(c.Aliases, ", ") + ")"
+			}
+			terms = append(terms, []string{name, lib.T(c.Summary)})
+		}
+		writeTerms(w, terms)
+		fmt.Fprintln(w)
+
+		// PATHにあるプラグイン
+		if plugins := Plugins(); len(plugins) > 0 {
+			fmt.Fprintln(w, lib.T("PLUGINS"))
+			terms = [][]string{}
+			for _, p := range plugins {
+				terms = append(terms, []string{p.Name, p.Summary})
+			}
+			writeTerms(w, terms)
+			fmt.Fprintln(w)
+		}
+		title = "GLOBAL OPTIONS"
+	}
+
+	if len(info.Flags) > 0 {
+		fmt.Fprintln(w, lib.T(title))
+		// 短い名前のオプションがない場合は字下げしない
+		short := false
+		for _, f := range info.Flags {
+			short = short || f.Short != ""
+		}
+
+		terms := [][]string{}
+		for _, f := range info.Flags {
+			label := flagLabel(f)
+			if !short {
+				label = strings.TrimLeft(label, " ")
+			}
+			terms = append(terms, []string{label, strings.Join(flagDescription(f), "\n")})
+		}
+		writeTerms(w, terms)
+		fmt.Fprintln(w)
+	}
+
+	for _, section := range info.Sections {
+		fmt.Fprintln(w, lib.T(section.Title))
+		terms := [][]string{}
+		for i, item := range section.Items {
+			if item.Term != "" {
+				terms = append(terms, []string{item.Term, lib.T(item.Text)})
+				continue
+			}
+
+			if i > 0 && item.Text != "" {
+				fmt.Fprintln(w)
+			}
+			indent := usageIndent
+			if item.Text != "" {
+				fmt.Fprintln(w, usageIndent+lib.T(item.Text))
+				indent += usageIndent
+			}
+			for _, line := range strings.Split(item.Command, "\n") {
+				fmt.Fprintln(w, indent+line)
+			}
+		}
+		writeTerms(w, terms)
+		fmt.Fprintln(w)
+	}
+}
+
+// 「conoha list」
+func commandName(info *CommandInfo) string {
+	if info == conohaInfo {
+		return "conoha"
+	}
+	return "conoha " + info.Name
+}
+
+// 「conoha list [OPTIONS]」
+func commandSynopsis(info *CommandInfo) string {
+	if info.Synopsis == "" {
+		return commandName(info)
+	}
+	return commandName(info) + " " + info.Synopsis
+}
+
+// 使い方に表示するコマンド
+func visibleCommands() []*CommandInfo {
+	result := []*CommandInfo{}
+	for _, info := range Commands() {
+		if !info.Hidden {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// 「-o, --output」「    --format」「-u」
+func flagLabel(f *FlagInfo) string {
+	switch {
+	case f.Short == "":
+		return "    --" + f.Name
+	case f.Name == "":
+		return "-" + f.Short
+	default:
+		return "-" + f.Short + ", --" + f.Name
+	}
+}
+
+// オプションの説明を翻訳して行に分ける
+// 例がある場合は説明の1行目の後に入れる
+func flagDescription(f *FlagInfo) []string {
+	lines := translateLines(f.Description)
+	if f.Example == "" {
+		return lines
+	}
+
+	example := lib.T("(e.g. %s)", "'"+f.Example+"'")
+	return append(lines[:1], append([]string{example}, lines[1:]...)...)
+}
+
+// 翻訳して行に分ける
+func translateLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(lib.T(text), "\n")
+}
+
+// 項目と説明を揃えて出力する
+// 項目に全角文字が含まれていても揃うように、幅は表示上の幅で数える
+// 説明が複数行の場合は2行目以降も同じ位置に揃える
+func writeTerms(w io.Writer, terms [][]string) {
+	width := 0
+	for _, term := range terms {
+		if n := runewidth.StringWidth(term[0]); n > width {
+			width = n
+		}
+	}
+	width += 2
+
+	for _, term := range terms {
+		for i, line := range strings.Split(term[1], "\n") {
+			label := ""
+			if i == 0 {
+				label = term[0]
+			}
+			fmt.Fprintln(w, strings.TrimRight(usageIndent+lib.PadRight(label, width)+line, " "))
+		}
+	}
+}
@@ -19,6 +19,7 @@ func (cmd *Version) parseFlag() error {
 }
 
 func (cmd *Version) Usage() {
+	printUsage("version")
 }
 
 func (cmd *Version) Run() error {
@@ -1,9 +1,9 @@
 package command
 
 import (
-	"errors"
-	"fmt"
-	"strconv"
+	"github.com/hironobu-s/conoha-vps/lib"
+	flag "github.com/ogier/pflag"
+	"strings"
 	"time"
 )
 
@@ -15,6 +15,13 @@ func NewVps() *Vps {
 
 type Vps struct {
 	info *VpsAddInformation
+
+	// キャッシュを使わずに最新の情報を取得する
+	refresh bool
+
+	// キャッシュを読み書きしない
+	noCache bool
+
 	*Command
 }
 
@@ -26,122 +33,242 @@ func (cmd *Vps) Run() error {
 	return nil
 }
 
-// VPSのステータス
-type ServerStatus int
+// キャッシュに関するオプションを追加する
+func (cmd *Vps) addCacheFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.refresh, "refresh", false, "")
+	fs.BoolVar(&cmd.noCache, "no-cache", false, "")
+}
 
-const (
-	StatusRunning       = 1  // 稼働中
-	StatusOffline       = 4  // 停止
-	StatusInUse         = 6  // 取得中
-	StatusInFormulation = 8  // サービス準備中
-	StatusNoinformation = 98 // 未取得
-	StatusUnknown       = 99
-)
+// オプションに応じてキャッシュの動作モードを設定する
+// フラグのパース直後に呼ぶこと
+func (cmd *Vps) applyCacheFlags() {
+	cache := lib.GetCacheInstance()
+	if cmd.noCache {
+		cache.Mode = lib.CacheDisabled
+	} else if cmd.refresh {
+		cache.Mode = lib.CacheRefresh
+	}
+}
 
-func (s ServerStatus) String() string {
-	switch s {
-	case StatusRunning:
-		return "Running"
-		//return "稼働中"
-	case StatusOffline:
-		return "Offline"
-		//return "停止"
-	case StatusInUse:
-		return "No status"
-		//return "取得中"
-	case StatusInFormulation:
-		return "Preparing"
-		//return "サービス準備中"
-	case StatusNoinformation:
-		return "-"
-		//return "未取得"
-	case StatusUnknown:
-		fallthrough
-	default:
-		return "Unknown"
-		//return "不明"
+// キャッシュを読み込んでvにセットする
+// --refresh の場合やwithRefresh()で作ったVpsでは、キャッシュを読まずにfalseを返す
+func (cmd *Vps) cacheGet(key string, v interface{}) bool {
+	if cmd.refresh {
+		return false
 	}
+	return lib.GetCacheInstance().Get(key, v)
+}
+
+// キャッシュを読まずに最新の情報を取得するVpsを返す
+// キャッシュの動作モードは全体で共有しているので、goroutineから使う処理では変更せずにこちらを使う
+func (cmd *Vps) withRefresh() *Vps {
+	v := *cmd
+	v.refresh = true
+	return &v
 }
 
 // 単一VPSを表す構造体
 // ServiceStatusとServerStatusは別物であることに注意
+// JSONなどで出力する場合のフィールド名はタグで指定する。互換性のため変更しないこと。
 type Vm struct {
-	Id            string
-	TrId          string // VPS削除などに使うもう一つのID。アプリケーション内のみで使用する。
-	ServerStatus  ServerStatus
-	Label         string
-	ServiceStatus string
-	ServiceId     string
-	Plan          string
-	CreatedAt     time.Time
-	DeleteDate    time.Time
-	PaymentSpan   string
+	Id            string       `json:"id" yaml:"id"`
+	TrId          string       `json:"-" yaml:"-"` // VPS削除などに使うもう一つのID。アプリケーション内のみで使用する。
+	ServerStatus  ServerStatus `json:"serverStatus" yaml:"serverStatus"`
+	Label         string       `json:"label" yaml:"label"`
+	ServiceStatus string       `json:"serviceStatus" yaml:"serviceStatus"`
+	ServiceId     string       `json:"serviceId" yaml:"serviceId"`
+	Plan          string       `json:"plan" yaml:"plan"`
+	CreatedAt     time.Time    `json:"createdAt" yaml:"createdAt"`
+	DeleteDate    time.Time    `json:"deleteDate" yaml:"deleteDate"`
+	PaymentSpan   string       `json:"paymentSpan" yaml:"paymentSpan"`
 
 	// 詳細情報
-	NumCpuCore        string
-	Memory            string
-	Disk1Size         string
-	Disk2Size         string
-	IPv4              string
-	IPv4netmask       string
-	IPv4gateway       string
-	IPv4dns1          string
-	IPv4dns2          string
-	IPv6              []string
-	IPv6prefix        string
-	IPv6gateway       string
-	IPv6dns1          string
-	IPv6dns2          string
-	House             string
-	CommonServerId    string
-	SerialConsoleHost string
-	IsoUploadHost     string
+	NumCpuCore        string   `json:"numCpuCore" yaml:"numCpuCore"`
+	Memory            string   `json:"memory" yaml:"memory"`
+	Disk1Size         string   `json:"disk1Size" yaml:"disk1Size"`
+	Disk2Size         string   `json:"disk2Size" yaml:"disk2Size"`
+	IPv4              string   `json:"ipv4" yaml:"ipv4"`
+	IPv4netmask       string   `json:"ipv4Netmask" yaml:"ipv4Netmask"`
+	IPv4gateway       string   `json:"ipv4Gateway" yaml:"ipv4Gateway"`
+	IPv4dns1          string   `json:"ipv4Dns1" yaml:"ipv4Dns1"`
+	IPv4dns2          string   `json:"ipv4Dns2" yaml:"ipv4Dns2"`
+	IPv6              []string `json:"ipv6" yaml:"ipv6"`
+	IPv6prefix        string   `json:"ipv6Prefix" yaml:"ipv6Prefix"`
+	IPv6gateway       string   `json:"ipv6Gateway" yaml:"ipv6Gateway"`
+	IPv6dns1          string   `json:"ipv6Dns1" yaml:"ipv6Dns1"`
+	IPv6dns2          string   `json:"ipv6Dns2" yaml:"ipv6Dns2"`
+	House             string   `json:"house" yaml:"house"`
+	CommonServerId    string   `json:"commonServerId" yaml:"commonServerId"`
+	SerialConsoleHost string   `json:"serialConsoleHost" yaml:"serialConsoleHost"`
+	IsoUploadHost     string   `json:"isoUploadHost" yaml:"isoUploadHost"`
 }
 
-// VPSを選択する
-func (cmd *Vps) vpsSelectMenu() (*Vm, error) {
-	var err error
+// 詳細情報をdetailからコピーする
+// ラベルやサービスのステータスなど一覧で取得した項目は、vmの値のままにする
+func (vm *Vm) mergeDetail(detail *Vm) {
+	// ステータスは一覧で取得していない場合だけコピーする
+	if vm.ServerStatus == StatusNoinformation {
+		vm.ServerStatus = detail.ServerStatus
+	}
 
-	// VPS一覧
-	vpsList := NewVpsList()
-	servers, err := vpsList.List(false)
-	if err != nil {
-		return nil, err
+	vm.NumCpuCore = detail.NumCpuCore
+	vm.Memory = detail.Memory
+	vm.Disk1Size = detail.Disk1Size
+	vm.Disk2Size = detail.Disk2Size
+	vm.IPv4 = detail.IPv4
+	vm.IPv4netmask = detail.IPv4netmask
+	vm.IPv4gateway = detail.IPv4gateway
+	vm.IPv4dns1 = detail.IPv4dns1
+	vm.IPv4dns2 = detail.IPv4dns2
+	vm.IPv6 = detail.IPv6
+	vm.IPv6prefix = detail.IPv6prefix
+	vm.IPv6gateway = detail.IPv6gateway
+	vm.IPv6dns1 = detail.IPv6dns1
+	vm.IPv6dns2 = detail.IPv6dns2
+	vm.House = detail.House
+	vm.CommonServerId = detail.CommonServerId
+	vm.SerialConsoleHost = detail.SerialConsoleHost
+	vm.IsoUploadHost = detail.IsoUploadHost
+}
+
+// キャッシュに保存するVPS
+// TrIdは出力しないが、キャッシュから読んだVPSを削除する場合などに必要なので一緒に保存する
+type cachedVm struct {
+	*Vm
+	TrId string `json:"trId"`
+}
+
+func newCachedVm(vm *Vm) *cachedVm {
+	return &cachedVm{Vm: vm, TrId: vm.TrId}
+}
+
+func (c *cachedVm) vm() *Vm {
+	if c.Vm == nil {
+		c.Vm = &Vm{}
 	}
+	c.Vm.TrId = c.TrId
+	return c.Vm
+}
 
-	// VPSが一つの場合はそれを返す
-	if len(servers) == 1 {
-		var vm *Vm
-		for _, vm = range servers {
-			break
+// Vmのフィールドを文字列として取り出すための定義
+// Keyは出力する場合のフィールド名(Vmのタグと同じ)
+type vmField struct {
+	Name  string
+	Key   string
+	Value func(vm *Vm) string
+}
+
+var vmFields = []vmField{
+	{"Id", "id", func(vm *Vm) string { return vm.Id }},
+	{"ServerStatus", "serverStatus", func(vm *Vm) string { return vm.ServerStatus.Name() }},
+	{"Label", "label", func(vm *Vm) string { return vm.Label }},
+	{"ServiceStatus", "serviceStatus", func(vm *Vm) string { return vm.ServiceStatus }},
+	{"ServiceId", "serviceId", func(vm *Vm) string { return vm.ServiceId }},
+	{"Plan", "plan", func(vm *Vm) string { return vm.Plan }},
+	{"CreatedAt", "createdAt", func(vm *Vm) string { return formatDate(vm.CreatedAt) }},
+	{"DeleteDate", "deleteDate", func(vm *Vm) string { return formatDate(vm.DeleteDate) }},
+	{"PaymentSpan", "paymentSpan", func(vm *Vm) string { return vm.PaymentSpan }},
+	{"NumCpuCore", "numCpuCore", func(vm *Vm) string { return vm.NumCpuCore }},
+	{"Memory", "memory", func(vm *Vm) string { return vm.Memory }},
+	{"Disk1Size", "disk1Size", func(vm *Vm) string { return vm.Disk1Size }},
+	{"Disk2Size", "disk2Size", func(vm *Vm) string { return vm.Disk2Size }},
+	{"IPv4", "ipv4", func(vm *Vm) string { return vm.IPv4 }},
+	{"IPv4netmask", "ipv4Netmask", func(vm *Vm) string { return vm.IPv4netmask }},
+	{"IPv4gateway", "ipv4Gateway", func(vm *Vm) string { return vm.IPv4gateway }},
+	{"IPv4dns1", "ipv4Dns1", func(vm *Vm) string { return vm.IPv4dns1 }},
+	{"IPv4dns2", "ipv4Dns2", func(vm *Vm) string { return vm.IPv4dns2 }},
+	{"IPv6", "ipv6", func(vm *Vm) string { return strings.Join(vm.IPv6, " ") }},
+	{"IPv6prefix", "ipv6Prefix", func(vm *Vm) string { return vm.IPv6prefix }},
+	{"IPv6gateway", "ipv6Gateway", func(vm *Vm) string { return vm.IPv6gateway }},
+	{"IPv6dns1", "ipv6Dns1", func(vm *Vm) string { return vm.IPv6dns1 }},
+	{"IPv6dns2", "ipv6Dns2", func(vm *Vm) string { return vm.IPv6dns2 }},
+	{"House", "house", func(vm *Vm) string { return vm.House }},
+	{"CommonServerId", "commonServerId", func(vm *Vm) string { return vm.CommonServerId }},
+	{"SerialConsoleHost", "serialConsoleHost", func(vm *Vm) string { return vm.SerialConsoleHost }},
+	{"IsoUploadHost", "isoUploadHost", func(vm *Vm) string { return vm.IsoUploadHost }},
+}
+
+// フィールド名(NameかKey)からvmFieldを探す
+func findVmField(name string) *vmField {
+	for i := range vmFields {
+		if vmFields[i].Name == name || vmFields[i].Key == name {
+			return &vmFields[i]
 		}
-		return vm, nil
-
-	} else {
-		var i int
-		ids := map[int]string{}
-		for i, vm := range servers {
-			fmt.Printf("[%d] %s\n", i+1, vm.Label)
-			ids[i] = vm.Id
-			i++
+	}
+	return nil
+}
+
+// 一覧(list)で出力するフィールド
+var vmListFields = []string{
+	"id", "label", "plan", "serverStatus", "serviceStatus", "serviceId", "createdAt", "deleteDate", "paymentSpan",
+}
+
+// 一覧(list --wide)で出力するフィールド
+var vmWideFields = append(append([]string{}, vmListFields...),
+	"ipv4", "ipv6", "numCpuCore", "memory", "disk1Size", "disk2Size", "house",
+)
+
+// VPSの一覧をCSVなどで出力するための表にする
+// fieldsがnilの場合は全てのフィールドを出力する
+func vmTable(servers []*Vm, fields []string) *lib.Table {
+	if fields == nil {
+		for _, f := range vmFields {
+			fields = append(fields, f.Key)
 		}
+	}
 
-		fmt.Printf("Please select VPS no. [1-%d]: ", len(servers))
+	t := &lib.Table{}
+	for _, name := range fields {
+		t.Header = append(t.Header, findVmField(name).Key)
+	}
 
-		var no string
-		if _, err = fmt.Scanf("%s", &no); err != nil {
-			return nil, err
+	for _, vm := range servers {
+		row := []string{}
+		for _, name := range fields {
+			row = append(row, findVmField(name).Value(vm))
 		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
 
-		i, err = strconv.Atoi(no)
-		if err != nil {
-			return nil, err
+// VPSを選択する
+func (cmd *Vps) vpsSelectMenu() (*Vm, error) {
+	servers, err := cmd.vpsSelect(false)
+	if err != nil {
+		return nil, err
+	}
+	return servers[0], nil
+}
 
-		} else if 1 <= i && i <= len(servers) {
-			return servers[i-1], nil
+// VPSを複数選択する
+func (cmd *Vps) vpsSelectMulti() ([]*Vm, error) {
+	return cmd.vpsSelect(true)
+}
 
-		} else {
-			return nil, errors.New("Invalid input(out of range).")
-		}
+func (cmd *Vps) vpsSelect(multi bool) ([]*Vm, error) {
+	servers, err := cmd.vpsSelectCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	// VPSが一つの場合はそれを返す
+	if len(servers) == 1 {
+		return servers, nil
+	}
+	return newVpsPicker(servers, multi).run()
+}
+
+// 選択肢にするVPSの一覧
+// 選択肢にはステータスも表示するので、ステータスも取得する
+func (cmd *Vps) vpsSelectCandidates() ([]*Vm, error) {
+	servers, err := (&VpsList{Vps: cmd}).List(true)
+	if err != nil {
+		return nil, requestError(err)
+	}
+
+	if len(servers) == 0 {
+		return nil, &VpsNotFoundError{}
 	}
+	return servers, nil
 }
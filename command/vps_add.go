@@ -9,7 +9,6 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"net/http"
 	"net/url"
 	"os"
@@ -67,7 +66,7 @@ type VpsAddInformation struct {
 
 func (i *VpsAddInformation) Validate() error {
 	if i.PlanType != PlanTypeBasic && i.PlanType != PlanTypeWindows {
-		return errors.New("Invalid PlanType.")
+		return errors.New(lib.T("Invalid PlanType."))
 	}
 
 	if i.Plan != Plan1G &&
@@ -76,29 +75,50 @@ func (i *VpsAddInformation) Validate() error {
 		i.Plan != Plan4G &&
 		i.Plan != Plan8G &&
 		i.Plan != Plan16G {
-		return errors.New("Invalid Plan.")
+		return errors.New(lib.T("Invalid Plan."))
 	}
 
 	if i.Template != TemplateDefault1 &&
 		i.Template != TemplateDefault2 &&
 		i.Template != TemplateDefault3 &&
 		i.Template != TemplateDefault4 {
-		return errors.New("Invalid Template.")
+		return errors.New(lib.T("Invalid Template."))
 	}
 	if i.PlanType == PlanTypeBasic && (i.Template == TemplateDefault3 || i.Template == TemplateDefault4) {
-		return errors.New("Invalid Template.")
+		return errors.New(lib.T("Invalid Template."))
 	}
 	if i.PlanType == PlanTypeWindows && (i.Template == TemplateDefault1 || i.Template == TemplateDefault1) {
-		return errors.New("Invalid Template.")
+		return errors.New(lib.T("Invalid Template."))
 	}
 
 	// 標準プランはrootパスワード必須
 	if i.PlanType == PlanTypeBasic && i.RootPassword == "" {
-		return errors.New("Root password is required.")
+		return errors.New(lib.T("Root password is required."))
 	}
 	return nil
 }
 
+// 監査ログに記録するパラメータ
+// コマンドラインで指定する値に戻しておく。パスワードは監査ログに書き込むときに伏せ字になる
+func (i *VpsAddInformation) auditParams() map[string]string {
+	types := map[int]string{PlanTypeBasic: "basic", PlanTypeWindows: "windows"}
+	plans := map[int]string{Plan1G: "1", Plan2G: "2", Plan4G: "4", Plan8G: "8", Plan16G: "16"}
+	images := map[int]string{
+		TemplateDefault1: "centos",
+		TemplateDefault2: "wordpress",
+		TemplateDefault3: "windows2012",
+		TemplateDefault4: "windows2008",
+	}
+
+	return map[string]string{
+		"type":      types[i.PlanType],
+		"plan":      plans[i.Plan],
+		"image":     images[i.Template],
+		"password":  i.RootPassword,
+		"sshkey-no": strconv.Itoa(i.SshKeyNo),
+	}
+}
+
 type VpsPlan struct {
 	label  string
 	planId string
@@ -122,15 +142,14 @@ func (cmd *VpsAdd) parseFlag() error {
 	var plan, sshKeyNo int
 	var err error
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
+	fs.BoolVarP(&help, "help", "h", false, "")
 	fs.StringVarP(&plantype, "type", "t", "", "")
 	fs.IntVarP(&plan, "plan", "p", -1, "")
 	fs.StringVarP(&template, "image", "i", "", "")
 	fs.StringVarP(&root, "password", "P", "", "")
-	fs.IntVarP(&sshKeyNo, "sshkey-no", "s", 1, ``)
+	fs.IntVarP(&sshKeyNo, "sshkey-no", "s", 1, "")
 
 	if err = fs.Parse(os.Args[1:]); err != nil {
 		return err
@@ -149,7 +168,7 @@ func (cmd *VpsAdd) parseFlag() error {
 		cmd.info.PlanType = PlanTypeWindows
 	} else {
 		fs.Usage()
-		return errors.New(`PlanType(-t) parameter should be "basic" or "windows".`)
+		return errors.New(lib.T(`PlanType(-t) parameter should be "basic" or "windows".`))
 	}
 
 	if plan == 1 {
@@ -164,7 +183,7 @@ func (cmd *VpsAdd) parseFlag() error {
 		cmd.info.Plan = Plan16G
 	} else {
 		fs.Usage()
-		return errors.New("Plan(-p) is invalid.")
+		return errors.New(lib.T("Plan(-p) is invalid."))
 	}
 
 	if template == "centos" {
@@ -177,7 +196,7 @@ func (cmd *VpsAdd) parseFlag() error {
 		cmd.info.Template = TemplateDefault4
 	} else {
 		fs.Usage()
-		return errors.New("Template Image(-i) is invalid.")
+		return errors.New(lib.T("Template Image(-i) is invalid."))
 	}
 
 	cmd.info.RootPassword = root
@@ -192,49 +211,21 @@ func (cmd *VpsAdd) parseFlag() error {
 }
 
 func (cd *VpsAdd) Usage() {
-	fmt.Println(`Usage: conoha add [OPTIONS]
-
-DESCRIPTION
-    Add VPS to your account.
-
-OPTIONS
-    -t, --type:     VPS Type.
-                    Is should be "basic" or "windows". If not set, it will set "basic".
-
-    -p: --plan:     VPS Plan.
-                    It allow only numeric(1=1G, 2=2G ... 16=16G).
-
-    -P: --password  Root password.
-                    If the VPS Type is "basic" only.
-
-    -i: --image:    Template image. It should be one of the following.
-                    ("centos" "wordpress" "windows2012" "windows2008")
-
-    -s: --sshkey-no:  SSH Key number. Default is 1.
-                      If the number of keys one, It wil be ignored.
-                     
-EXAMPLE
-    Standard Plan, 2vCPU, 1GB Memory and CentOS6.5.
-        conoha add -t basic -p 1 -i centos -P {password}
-
-    Standard Plan, 4vCPU, 4GB Memory and CentOS6.5 + nginx + WordPress.
-        conoha add -t basic -p 4 -i wordpress -P {password}
-
-    Windows Plan, 8vCPU, 8GB Memory and Windows Server 2012 R2
-        conoha add -t windows -p 8 -i windows2012
-
-    Windows Plan, 16vCPU, 16GB Memory and Windows Server 2008 R2
-        conoha add -t windows -p 16 -i windows2008
-`)
+	printUsage("add")
 }
 
 func (cmd *VpsAdd) Run() error {
 	var err error
 	if err = cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
 	}
 
-	return cmd.Add(cmd.info)
+	record := cmd.newAuditRecord(lib.AuditAdd, "")
+	record.Params = cmd.info.auditParams()
+
+	err = cmd.Add(cmd.info)
+	cmd.audit(record, err)
+	return err
 }
 
 func (cmd *Vps) Add(info *VpsAddInformation) error {
@@ -264,11 +255,14 @@ func (cmd *Vps) Add(info *VpsAddInformation) error {
 	}
 	cmd.browser.AddAction(act)
 
+	// VPSの一覧が変わるのでキャッシュを削除する
+	defer cmd.clearCache()
+
 	if err := cmd.browser.Run(); err != nil {
-		return err
+		return showDryRun(err)
 	}
 
-	log.Infof("Adding VPS is complete.")
+	log.Info(lib.T("Adding VPS is complete."))
 
 	return nil
 }
@@ -314,7 +308,7 @@ func (r *addFormResult) detectPlans(doc *goquery.Document, planType int) (plans
 	} else if planType == PlanTypeWindows {
 		sel = doc.Find("#trWindowsPlan LI")
 	} else {
-		return nil, errors.New("Undefined plan type.")
+		return nil, errors.New(lib.T("Undefined plan type."))
 	}
 
 	i := 1
@@ -341,7 +335,7 @@ func (r *addFormResult) detectPlans(doc *goquery.Document, planType int) (plans
 	}
 
 	if len(plans) != 5 {
-		return nil, errors.New("The number of Linux plans is not 5.")
+		return nil, cpanel.NewMarkupError("The number of Linux plans is not 5.")
 	}
 
 	return plans, nil
@@ -355,7 +349,7 @@ func (r *addFormResult) sshKeyId(doc *goquery.Document) (string, error) {
 	if sshKeyId != "" {
 		return sshKeyId, nil
 	} else {
-		return "", errors.New("SSH key not found.")
+		return "", &NotFoundError{lib.T("SSH key not found.")}
 	}
 }
 
@@ -443,7 +437,7 @@ func (r addConfirmResult) Populate(resp *http.Response, doc *goquery.Document) e
 	sel = doc.Find("#ContentPlaceHolder1_ContentPlaceHolder1_btnExecute")
 	v, _ := sel.Attr("value")
 	if v == "" {
-		return errors.New("Server returned invalid html(Submit button is not included).")
+		return cpanel.NewMarkupError("Server returned invalid html(Submit button is not included).")
 	}
 
 	return nil
@@ -454,6 +448,9 @@ func (r addConfirmResult) Populate(resp *http.Response, doc *goquery.Document) e
 type addSubmitRequest struct {
 }
 
+// VPSを追加するのでドライランでは送信しない
+func (r *addSubmitRequest) Irreversible() {}
+
 func (r *addSubmitRequest) NewRequest(values url.Values) (*http.Request, error) {
 	values.Add("ctl00$ctl00$ContentPlaceHolder1$ContentPlaceHolder1$btnExecute", "決定")
 
@@ -0,0 +1,214 @@
+package command
+
+// VPSの詳細情報を比較する
+// スナップショット同士、もしくはスナップショットと現在の状態の差分を表示する
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+)
+
+// diffコマンドで比較するフィールド
+// ServerStatusは頻繁に変わるのでデフォルトでは比較しない
+var diffFields = []string{
+	"Label", "ServiceStatus", "ServiceId", "Plan", "CreatedAt", "DeleteDate", "PaymentSpan",
+	"NumCpuCore", "Memory", "Disk1Size", "Disk2Size",
+	"IPv4", "IPv4netmask", "IPv4gateway", "IPv4dns1", "IPv4dns2",
+	"IPv6", "IPv6prefix", "IPv6gateway", "IPv6dns1", "IPv6dns2",
+	"House", "CommonServerId", "SerialConsoleHost", "IsoUploadHost",
+}
+
+// フィールドの変更
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// 二つのVmの指定したフィールドを比較して、変更のあったものを返す
+func compareVm(a *Vm, b *Vm, fields []string) []*FieldChange {
+	changes := []*FieldChange{}
+
+	for _, name := range fields {
+		f := findVmField(name)
+		if f == nil {
+			continue
+		}
+
+		old, new := f.Value(a), f.Value(b)
+		if old != new {
+			changes = append(changes, &FieldChange{
+				Field: f.Name,
+				Old:   old,
+				New:   new,
+			})
+		}
+	}
+	return changes
+}
+
+// 単一VPSの差分
+type VmDiff struct {
+	Id      string
+	Label   string
+	Added   bool
+	Removed bool
+	Changes []*FieldChange
+}
+
+// 二つのVPS一覧を比較する
+// 差分のあったVPSのみを返す
+func diffVmList(a []*Vm, b []*Vm, fields []string) []*VmDiff {
+	diffs := []*VmDiff{}
+
+	olds := map[string]*Vm{}
+	for _, vm := range a {
+		olds[vmKey(vm)] = vm
+	}
+
+	news := map[string]bool{}
+	for _, vm := range b {
+		key := vmKey(vm)
+		news[key] = true
+
+		old, exists := olds[key]
+		if !exists {
+			diffs = append(diffs, &VmDiff{
+				Id:    vm.Id,
+				Label: vm.Label,
+				Added: true,
+			})
+			continue
+		}
+
+		if changes := compareVm(old, vm, fields); len(changes) > 0 {
+			diffs = append(diffs, &VmDiff{
+				Id:      vm.Id,
+				Label:   vm.Label,
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, vm := range a {
+		if !news[vmKey(vm)] {
+			diffs = append(diffs, &VmDiff{
+				Id:      vm.Id,
+				Label:   vm.Label,
+				Removed: true,
+			})
+		}
+	}
+	return diffs
+}
+
+// --------------------------------
+
+type VpsDiff struct {
+	from          string
+	to            string
+	includeStatus bool
+	*Vps
+}
+
+func NewVpsDiff() *VpsDiff {
+	return &VpsDiff{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *VpsDiff) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.BoolVarP(&cmd.includeStatus, "include-status", "s", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	args := fs.Args()
+	if len(args) < 2 {
+		fs.Usage()
+		return errors.New(lib.T("Not enough arguments."))
+	} else if len(args) > 3 {
+		fs.Usage()
+		return errors.New(lib.T("Too many arguments."))
+	}
+
+	cmd.from = args[1]
+	if len(args) == 3 {
+		cmd.to = args[2]
+	}
+	return nil
+}
+
+func (cmd *VpsDiff) Usage() {
+	printUsage("diff")
+}
+
+func (cmd *VpsDiff) Run() error {
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	from, err := LoadSnapshot(cmd.config, cmd.from)
+	if err != nil {
+		return err
+	}
+
+	// 詳細を取得できなかったVPSがあっても、取得できた分で差分を表示してからエラーを返す
+	var to *Snapshot
+	var takeErr error
+	if cmd.to != "" {
+		to, err = LoadSnapshot(cmd.config, cmd.to)
+	} else {
+		to, takeErr = (&VpsSnapshot{Vps: cmd.Vps}).Take("(current)")
+		if to == nil {
+			err = takeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := diffFields
+	if cmd.includeStatus {
+		fields = append([]string{"ServerStatus"}, fields...)
+	}
+
+	diffs := diffVmList(from.Servers, to.Servers, fields)
+
+	fmt.Printf("--- %s (%s)\n", from.Name, from.CreatedAt.Format("2006/01/02 15:04 MST"))
+	fmt.Printf("+++ %s (%s)\n", to.Name, to.CreatedAt.Format("2006/01/02 15:04 MST"))
+
+	for _, d := range diffs {
+		switch {
+		case d.Added:
+			fmt.Printf("+ %s (%s)\n", d.Id, d.Label)
+		case d.Removed:
+			fmt.Printf("- %s (%s)\n", d.Id, d.Label)
+		default:
+			fmt.Printf("~ %s (%s)\n", d.Id, d.Label)
+			for _, c := range d.Changes {
+				fmt.Printf("    %-18s %q -> %q\n", c.Field+":", c.Old, c.New)
+			}
+		}
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println(lib.T("No differences."))
+	}
+	return takeErr
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiffVmList(t *testing.T) {
+	a := []*Vm{
+		{Id: "aaa", Label: "web", Plan: "1GB Memory", IPv4: "192.0.2.1", House: "cnode-f0001"},
+		{Id: "bbb", Label: "db", Plan: "4GB Memory"},
+	}
+
+	b := []*Vm{
+		{Id: "aaa", Label: "web", Plan: "2GB Memory", IPv4: "192.0.2.1", House: "cnode-f0002", ServerStatus: StatusOffline},
+		{Id: "ccc", Label: "new"},
+	}
+
+	diffs := diffVmList(a, b, diffFields)
+	if len(diffs) != 3 {
+		t.Fatalf("the number of diffs should be 3. [%d]", len(diffs))
+	}
+
+	// ServerStatusは比較対象に含めていない
+	if d := diffs[0]; d.Id != "aaa" || len(d.Changes) != 2 {
+		t.Errorf("wrong diff. [%#v]", d)
+	} else {
+		if d.Changes[0].Field != "Plan" || d.Changes[0].Old != "1GB Memory" || d.Changes[0].New != "2GB Memory" {
+			t.Errorf("wrong change. [%#v]", d.Changes[0])
+		}
+		if d.Changes[1].Field != "House" {
+			t.Errorf("wrong change. [%#v]", d.Changes[1])
+		}
+	}
+
+	if d := diffs[1]; d.Id != "ccc" || !d.Added {
+		t.Errorf("ccc should be added. [%#v]", d)
+	}
+
+	if d := diffs[2]; d.Id != "bbb" || !d.Removed {
+		t.Errorf("bbb should be removed. [%#v]", d)
+	}
+}
+
+func TestVpsDiffArgs(t *testing.T) {
+	argv := os.Args
+	defer func() { os.Args = argv }()
+
+	tests := map[string]string{
+		"diff":       "Not enough arguments.",
+		"diff a b c": "Too many arguments.",
+		"diff a b":   "",
+		"diff -s a":  "",
+	}
+	for args, want := range tests {
+		os.Args = append([]string{"conoha"}, strings.Fields(args)...)
+		cmd := &VpsDiff{Vps: &Vps{Command: &Command{}}}
+		err := cmd.parseFlag()
+		if (want == "" && err != nil) || (want != "" && (err == nil || err.Error() != want)) {
+			t.Errorf("%s: got %v", args, err)
+		}
+	}
+}
+
+func TestVpsDiffPartial(t *testing.T) {
+	defer setupHome(t, `{"Account":"C12345678"}`)()
+	config := &lib.Config{}
+	config.Read()
+
+	s := &Snapshot{Name: "before", Servers: []*Vm{{Id: "f648a6646b7e7d91", Label: "old"}}}
+	if _, err := s.Save(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 詳細を取得できなかったVPSがあっても差分は表示する
+	transport := http.DefaultTransport
+	http.DefaultTransport = &fakePanel{failStatus: true}
+	defer func() { http.DefaultTransport = transport }()
+
+	argv := os.Args
+	defer func() { os.Args = argv }()
+	os.Args = []string{"conoha", "diff", "before"}
+
+	cmd := &VpsDiff{Vps: &Vps{Command: &Command{config: config, browser: cpanel.NewBrowser().Fork()}}}
+	out, err := captureStdout(cmd.Run)
+	if err == nil || !strings.Contains(out, "+ a2ae45355615d641 (db)") || !strings.Contains(out, `"old" -> "web"`) {
+		t.Errorf("got %q [%v]", out, err)
+	}
+}
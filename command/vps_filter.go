@@ -0,0 +1,237 @@
+package command
+
+// VPSの一覧の絞り込みと並べ替え
+// listコマンドの --filter --sort --reverse --limit で使う
+
+import (
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// フィールド名の別名
+var vmFieldAliases = map[string]string{
+	"status":  "serverStatus",
+	"service": "serviceStatus",
+	"created": "createdAt",
+	"deleted": "deleteDate",
+	"ip":      "ipv4",
+	"host":    "house",
+}
+
+// 比較演算子
+// 長いものから順に並べておくこと
+var filterOperators = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">"}
+
+// フィールド名からvmFieldを探す
+// 大文字小文字は区別せず、別名も使える
+func lookupVmField(name string) (*vmField, error) {
+	name = strings.TrimSpace(name)
+	if alias, ok := vmFieldAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+
+	for i := range vmFields {
+		if strings.EqualFold(vmFields[i].Key, name) || strings.EqualFold(vmFields[i].Name, name) {
+			return &vmFields[i], nil
+		}
+	}
+	return nil, errors.New(lib.T(`Undefined field "%s".`, name))
+}
+
+// --filter で指定された条件
+type vmFilter struct {
+	field *vmField
+	op    string
+	value string
+}
+
+// "status=Running" "plan~Windows" "label=web-*" "created<2015-01-01" のような式をパースする
+func parseVmFilter(expr string) (*vmFilter, error) {
+	for i := 0; i < len(expr); i++ {
+		for _, op := range filterOperators {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+
+			field, err := lookupVmField(expr[:i])
+			if err != nil {
+				return nil, err
+			}
+
+			value := strings.TrimSpace(expr[i+len(op):])
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				if value, err = strconv.Unquote(value); err != nil {
+					return nil, errors.New(lib.T(`Invalid filter "%s".`, expr))
+				}
+			}
+
+			return &vmFilter{
+				field: field,
+				op:    op,
+				value: value,
+			}, nil
+		}
+	}
+	return nil, errors.New(lib.T(`Invalid filter "%s". It should be like "status=Running".`, expr))
+}
+
+// VPSが条件に一致すればtrueを返す
+func (f *vmFilter) Match(vm *Vm) bool {
+	actual := f.field.Value(vm)
+
+	switch f.op {
+	case "=":
+		return matchGlob(f.value, actual)
+	case "!=":
+		return !matchGlob(f.value, actual)
+	case "~":
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(f.value))
+	case "!~":
+		return !strings.Contains(strings.ToLower(actual), strings.ToLower(f.value))
+	}
+
+	c, ok := compareFieldValues(f.field, actual, f.value)
+	if !ok {
+		return false
+	}
+
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// 大文字小文字を区別せずにワイルドカード(* ?)で比較する
+func matchGlob(pattern string, s string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(s))
+	if err != nil {
+		return strings.EqualFold(pattern, s)
+	}
+	return matched
+}
+
+// 日付として解釈できる書式
+var filterDateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006/01/02 15:04", "2006-01-02", "2006/01/02"}
+
+func parseFilterDate(s string) (time.Time, bool) {
+	for _, layout := range filterDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// フィールドの値を比較する
+// 日付のフィールドは日付として、数値の場合は数値として比較する
+// aがbより小さければ負の値、等しければ0、大きければ正の値を返す
+// 比較できない場合(日付がない場合など)は二番目の返り値がfalseになる
+func compareFieldValues(field *vmField, a string, b string) (int, bool) {
+	if field.Key == "createdAt" || field.Key == "deleteDate" {
+		ta, ok1 := parseFilterDate(a)
+		tb, ok2 := parseFilterDate(b)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	na, err1 := strconv.ParseFloat(a, 64)
+	nb, err2 := strconv.ParseFloat(b, 64)
+	if err1 == nil && err2 == nil {
+		switch {
+		case na < nb:
+			return -1, true
+		case na > nb:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b)), true
+}
+
+// 全ての条件に一致するVPSを返す
+func filterVm(servers []*Vm, filters []*vmFilter) []*Vm {
+	result := []*Vm{}
+
+	for _, vm := range servers {
+		matched := true
+		for _, f := range filters {
+			if !f.Match(vm) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			result = append(result, vm)
+		}
+	}
+	return result
+}
+
+// VPSの一覧を指定したフィールドで並べ替える
+// reverseを指定しても、日付がないものは後ろにする
+type vmSorter struct {
+	servers []*Vm
+	field   *vmField
+	reverse bool
+}
+
+func (s *vmSorter) Len() int      { return len(s.servers) }
+func (s *vmSorter) Swap(i, j int) { s.servers[i], s.servers[j] = s.servers[j], s.servers[i] }
+func (s *vmSorter) Less(i, j int) bool {
+	a, b := s.field.Value(s.servers[i]), s.field.Value(s.servers[j])
+
+	c, ok := compareFieldValues(s.field, a, b)
+	if !ok {
+		// 日付がないものは後ろにする
+		return a != "" && b == ""
+	}
+	if s.reverse {
+		return c > 0
+	}
+	return c < 0
+}
+
+func sortVm(servers []*Vm, field *vmField, reverse bool) {
+	sort.Stable(&vmSorter{servers: servers, field: field, reverse: reverse})
+}
+
+// 複数回指定できるフラグ(--filter)
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func (s *stringsFlag) Type() string {
+	return "strings"
+}
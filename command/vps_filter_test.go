@@ -0,0 +1,90 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func testFilterServers() []*Vm {
+	return []*Vm{
+		{Id: "aaa", Label: "web-01", Plan: "Windows Server 2GB", ServerStatus: StatusRunning, CreatedAt: time.Date(2014, 10, 1, 0, 0, 0, 0, time.Local)},
+		{Id: "bbb", Label: "db-01", Plan: "Linux 4GB", ServerStatus: StatusOffline, CreatedAt: time.Date(2015, 3, 1, 0, 0, 0, 0, time.Local)},
+		{Id: "ccc", Label: "web-02", Plan: "Linux 1GB", ServerStatus: StatusRunning, CreatedAt: time.Date(2015, 1, 15, 0, 0, 0, 0, time.Local)},
+	}
+}
+
+func filterIds(t *testing.T, exprs ...string) []string {
+	filters := []*vmFilter{}
+	for _, expr := range exprs {
+		f, err := parseVmFilter(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filters = append(filters, f)
+	}
+
+	ids := []string{}
+	for _, vm := range filterVm(testFilterServers(), filters) {
+		ids = append(ids, vm.Id)
+	}
+	return ids
+}
+
+func TestFilterVm(t *testing.T) {
+	tests := []struct {
+		exprs []string
+		ids   string
+	}{
+		{[]string{"status=running"}, "aaa,ccc"},
+		{[]string{`plan~"Windows"`}, "aaa"},
+		{[]string{"label=web-*"}, "aaa,ccc"},
+		{[]string{"created<2015-01-01"}, "aaa"},
+		{[]string{"label=web-*", "created>=2015-01-01"}, "ccc"},
+		{[]string{"Plan!~linux"}, "aaa"},
+		{[]string{"status!=Running"}, "bbb"},
+	}
+
+	for _, test := range tests {
+		ids := ""
+		for i, id := range filterIds(t, test.exprs...) {
+			if i > 0 {
+				ids += ","
+			}
+			ids += id
+		}
+		if ids != test.ids {
+			t.Errorf("%v should match %s. [%s]", test.exprs, test.ids, ids)
+		}
+	}
+}
+
+func TestParseVmFilterError(t *testing.T) {
+	for _, expr := range []string{"status", "foo=bar", "=Running"} {
+		if _, err := parseVmFilter(expr); err == nil {
+			t.Errorf("%s should be an error", expr)
+		}
+	}
+}
+
+func TestSortVm(t *testing.T) {
+	servers := testFilterServers()
+
+	f, err := lookupVmField("created")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sortVm(servers, f, true)
+	if servers[0].Id != "bbb" || servers[1].Id != "ccc" || servers[2].Id != "aaa" {
+		t.Errorf("wrong order. [%s %s %s]", servers[0].Id, servers[1].Id, servers[2].Id)
+	}
+
+	// 日付がないものは逆順でも後ろにする
+	servers = append([]*Vm{{Id: "ddd"}}, servers...)
+	for _, reverse := range []bool{false, true} {
+		sortVm(servers, f, reverse)
+		if servers[3].Id != "ddd" {
+			t.Errorf("VPS without the date should be last. [reverse=%v, %s]", reverse, servers[0].Id)
+		}
+	}
+}
@@ -2,11 +2,9 @@ package command
 
 import (
 	"errors"
-	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"net/http"
 	"net/url"
 	"os"
@@ -30,11 +28,10 @@ func NewVpsLabel() *VpsLabel {
 func (cmd *VpsLabel) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.StringVarP(&cmd.label, "label", "l", "", "Label")
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.label, "label", "l", "", "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
@@ -47,11 +44,11 @@ func (cmd *VpsLabel) parseFlag() error {
 	}
 
 	if cmd.label == "" {
-		return errors.New("Not enough arguments.")
+		return errors.New(lib.T("Not enough arguments."))
 	}
 
 	if len(cmd.label) > 20 {
-		return errors.New("Label is too long(should be 20 characters or less). ")
+		return errors.New(lib.T("Label is too long(should be 20 characters or less). "))
 	}
 
 	// VPS-ID
@@ -64,33 +61,31 @@ func (cmd *VpsLabel) parseFlag() error {
 		cmd.vmId = vm.Id
 
 	} else {
-		cmd.vmId = os.Args[2]
+		cmd.vmId = fs.Arg(1)
 	}
 	return nil
 }
 
 func (cmd *VpsLabel) Usage() {
-	fmt.Println(`Usage: conoha label <VPS-ID> [OPTIONS ...]
-
-DESCRIPTION
-    Change VPS label.
-
-<VPS-ID> (Optional) VPS-ID to get the stats. It may be confirmed by LIST subcommand.
-         If not set, It will be selected from the list menu.
-
-OPTIONS
-    -l: --label:    name of label.
-    -h: --help:     Show usage.      
-`)
+	printUsage("label")
 }
 
 func (cmd *VpsLabel) Run() error {
 	var err error
 	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+	if cmd.vmId, err = cmd.resolveVmId(cmd.vmId); err != nil {
 		return err
 	}
+	lib.AddLogField("vps_id", cmd.vmId)
 
-	return cmd.Change(cmd.vmId, cmd.label)
+	record := cmd.newAuditRecord(lib.AuditLabel, cmd.vmId)
+	record.Params = map[string]string{"label": cmd.label}
+
+	err = cmd.Change(cmd.vmId, cmd.label)
+	cmd.audit(record, err)
+	return err
 }
 
 func (cmd *VpsLabel) Change(vmId string, label string) error {
@@ -104,12 +99,15 @@ func (cmd *VpsLabel) Change(vmId string, label string) error {
 	}
 	cmd.browser.AddAction(act)
 
+	// ラベルが変わるのでキャッシュを削除する
+	defer cmd.clearCache()
+
 	if err := cmd.browser.Run(); err != nil {
-		return err
+		return showDryRun(err)
 	}
 
 	log := lib.GetLogInstance()
-	log.Infof(`VPS Label was changed to "%s"`, cmd.label)
+	log.Info(lib.T(`VPS Label was changed to "%s"`, cmd.label))
 
 	return nil
 }
@@ -119,6 +117,9 @@ type labelChangeRequest struct {
 	label string
 }
 
+// ラベルを変更するのでドライランでは送信しない
+func (r *labelChangeRequest) Irreversible() {}
+
 func (r *labelChangeRequest) NewRequest(values url.Values) (*http.Request, error) {
 	values = url.Values{}
 	values.Add("eid", r.vmId)
@@ -139,7 +140,7 @@ type labelChangeResult struct{}
 func (r *labelChangeResult) Populate(resp *http.Response, doc *goquery.Document) error {
 
 	if resp.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("Server returned the errror status code(%d).", resp.StatusCode))
+		return errors.New(lib.T("Server returned the errror status code(%d).", resp.StatusCode))
 	}
 
 	return nil
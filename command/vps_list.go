@@ -9,11 +9,10 @@ import (
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
-	flag "github.com/ogier/pflag"
+	"github.com/hironobu-s/conoha-vps/lib"
 	"net/http"
 	"net/url"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,8 +20,15 @@ import (
 
 type VpsList struct {
 	*Vps
-	idOnly  bool
-	verbose bool
+	idOnly   bool
+	verbose  bool
+	wide     bool
+	parallel int
+	color    string
+	filters  []*vmFilter
+	sortBy   *vmField
+	reverse  bool
+	limit    int
 }
 
 func NewVpsList() *VpsList {
@@ -33,99 +39,193 @@ func NewVpsList() *VpsList {
 
 func (cmd *VpsList) parseFlag() error {
 	var help bool
-
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
-
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.BoolVarP(&cmd.idOnly, "id-only", "i", false, "id-only")
-	fs.BoolVarP(&cmd.verbose, "Verbose", "v", true, "Verbose output.")
+	var filters stringsFlag
+	var sortBy string
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.BoolVarP(&cmd.idOnly, "id-only", "i", false, "")
+	fs.BoolVarP(&cmd.verbose, "Verbose", "v", true, "")
+	fs.BoolVarP(&cmd.wide, "wide", "w", false, "")
+	fs.IntVarP(&cmd.parallel, "parallel", "p", DefaultParallel, "")
+	fs.VarP(&filters, "filter", "f", "")
+	fs.StringVarP(&sortBy, "sort", "s", "", "")
+	fs.BoolVarP(&cmd.reverse, "reverse", "r", false, "")
+	fs.IntVarP(&cmd.limit, "limit", "n", 0, "")
+	fs.StringVar(&cmd.color, "color", lib.ColorAuto, "")
+	cmd.addCacheFlags(fs)
+	cmd.addOutputFlag(fs)
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
 		return err
 	}
+	cmd.applyCacheFlags()
 
 	if help {
 		fs.Usage()
 		return &ShowUsageError{}
 	}
 
+	if err := cmd.validateOutputFlag(); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	for _, expr := range filters {
+		f, err := parseVmFilter(expr)
+		if err != nil {
+			return err
+		}
+		cmd.filters = append(cmd.filters, f)
+	}
+
+	if sortBy != "" {
+		f, err := lookupVmField(sortBy)
+		if err != nil {
+			return err
+		}
+		cmd.sortBy = f
+	}
+
+	if cmd.limit < 0 {
+		return errors.New(lib.T("--limit should be 0 or greater(0 means no limit)."))
+	}
+
+	if err := lib.ValidateColorMode(cmd.color); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if cmd.parallel < 1 {
+		return errors.New(lib.T("--parallel should be a positive number."))
+	}
+
 	return nil
 }
 
 func (cd *VpsList) Usage() {
-	fmt.Println(`Usage: conoha list [OPTIONS]
-
-DESCRIPTION
-    List VPS status.
-
-OPTIONS
-    -h: --help:     Show usage.
-    -i: --id-only:  Show VPS-ID only.
-    -v: --verbose:  Verbose output(default is true).
-                    It will be included the server status, but slowly.
-`)
+	printUsage("list")
 }
 
 func (cmd *VpsList) Run() error {
 	var err error
 
 	if err = cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
 	}
 
+	// --wide の場合はステータスも詳細と一緒に取得する
 	var servers []*Vm
-	servers, err = cmd.List(cmd.verbose)
+	servers, err = cmd.List(cmd.verbose && !cmd.wide)
 	if err != nil {
 		return err
 	}
 
+	// 詳細の取得に失敗したVPSがあっても一覧は表示する
+	var failed int
+	if cmd.wide {
+		log := lib.GetLogInstance()
+		for i, err := range cmd.Details(servers, cmd.parallel) {
+			if err != nil {
+				log.Warn(lib.T("Could not get the details of VPS(id=%s): %s", servers[i].Id, err))
+				failed++
+			}
+		}
+	}
+
+	servers = filterVm(servers, cmd.filters)
+	if cmd.sortBy != nil {
+		sortVm(servers, cmd.sortBy, cmd.reverse)
+	} else if cmd.reverse {
+		for i, j := 0, len(servers)-1; i < j; i, j = i+1, j-1 {
+			servers[i], servers[j] = servers[j], servers[i]
+		}
+	}
+	if cmd.limit > 0 && len(servers) > cmd.limit {
+		servers = servers[:cmd.limit]
+	}
+
 	if cmd.idOnly {
 		format := "%-20s\n"
 		for _, vm := range servers {
 			fmt.Printf(format, vm.Id)
 		}
 
-	} else {
-
-		var maxPlan int = 10
-		var maxLabel int = 10
+	} else if cmd.template != nil {
+		items := []interface{}{}
 		for _, vm := range servers {
-			if len(vm.Label) > maxLabel {
-				maxLabel = len(vm.Label)
-			}
-			if len(vm.Plan) > maxPlan {
-				maxPlan = len(vm.Plan)
-			}
+			items = append(items, vm)
+		}
+		if err = lib.RenderTemplate(os.Stdout, cmd.template, items...); err != nil {
+			return err
 		}
 
-		format := "%-20s\t%-" + strconv.Itoa(maxLabel) + "s\t%-" + strconv.Itoa(maxPlan) + "s\t%-15s\t%-20s\t%s\n"
-
-		fmt.Printf(
-			format,
-			"VPS ID           ",
-			"Label",
-			"Plan",
-			"Server Status",
-			"Service Status",
-			"CreatedAt",
-		)
-		for _, vm := range servers {
-			fmt.Printf(
-				format,
-				vm.Id,
-				vm.Label,
-				vm.Plan,
-				vm.ServerStatus,
-				vm.ServiceStatus,
-				vm.CreatedAt.Format("2006/01/02 15:04 MST"),
-			)
+	} else if cmd.output != lib.OutputTable {
+		fields := vmListFields
+		if cmd.wide {
+			fields = vmWideFields
+		}
+		if err = lib.Render(os.Stdout, cmd.output, servers, vmTable(servers, fields)); err != nil {
+			return err
+		}
+
+	} else {
+		opts := &lib.TableOptions{
+			MaxWidth:     lib.TerminalWidth(os.Stdout),
+			FixedColumns: vmListFixedColumns,
+			Color:        lib.UseColor(cmd.color, os.Stdout),
+			CellColor: func(row int, col int) lib.Color {
+				if col == vmListStatusColumn {
+					return servers[row].ServerStatus.Color()
+				}
+				return lib.ColorNone
+			},
+		}
+		if err = lib.RenderTable(os.Stdout, vmListTable(servers, cmd.wide), opts); err != nil {
+			return err
 		}
 	}
+
+	if failed > 0 {
+		return errors.New(lib.T("Could not get the details of %d VPS.", failed))
+	}
 	return nil
 }
 
+// 一覧の表でServerStatusを表示する列
+const vmListStatusColumn = 3
+
+// 端末の幅に収まらない場合でも切り詰めない列(VPS ID, Server Status, CreatedAt, IPv4)
+var vmListFixedColumns = []int{0, vmListStatusColumn, 5, 6}
+
+// 一覧を表で出力するための表にする
+func vmListTable(servers []*Vm, wide bool) *lib.Table {
+	t := &lib.Table{
+		Header: []string{lib.T("VPS ID"), lib.T("Label"), lib.T("Plan"), lib.T("Server Status"), lib.T("Service Status"), lib.T("CreatedAt")},
+	}
+	if wide {
+		t.Header = append(t.Header, lib.T("IPv4"), lib.T("CPU"), lib.T("Memory"), lib.T("House"))
+	}
+
+	for _, vm := range servers {
+		row := []string{
+			vm.Id,
+			vm.Label,
+			vm.Plan,
+			vm.ServerStatus.String(),
+			vm.ServiceStatus,
+			vm.CreatedAt.Format("2006/01/02 15:04 MST"),
+		}
+		if wide {
+			row = append(row, vm.IPv4, vm.NumCpuCore, vm.Memory, vm.House)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
 // Vmを取得する
 // 引数のIDのVmが見つかった場合はその構造体を、見つからない場合はnilを返す。
 func (cmd *VpsList) Vm(vmId string) *Vm {
@@ -149,6 +249,20 @@ func (cmd *VpsList) Vm(vmId string) *Vm {
 // 引数のdeepCrawlをtrueにすると、VMのステータスも取得する
 func (cmd *VpsList) List(deep bool) (servers []*Vm, err error) {
 
+	// キャッシュが有効であればそれを返す
+	cache := lib.GetCacheInstance()
+	key := "list"
+	if deep {
+		key = "list-status"
+	}
+	var cached []*cachedVm
+	if cmd.cacheGet(key, &cached) {
+		for _, c := range cached {
+			servers = append(servers, c.vm())
+		}
+		return servers, nil
+	}
+
 	var act *cpanel.Action
 
 	r := &listResult{}
@@ -164,31 +278,104 @@ func (cmd *VpsList) List(deep bool) (servers []*Vm, err error) {
 	}
 
 	// サーバーステータスを取得する
+	failed := false
 	if deep {
-		wait := new(sync.WaitGroup)
+		errs := cmd.eachVm(r.servers, DefaultParallel, func(browser *cpanel.Browser, vm *Vm) error {
+			status, err := getVMStatus(browser, vm.Id)
+			if err != nil {
+				status = StatusUnknown
+			}
+			vm.ServerStatus = status
+			return err
+		})
+		for _, err := range errs {
+			if err != nil {
+				failed = true
+			}
+		}
 
+	} else {
 		for _, vm := range r.servers {
+			vm.ServerStatus = StatusNoinformation
+		}
+	}
+
+	// 取得できなかったステータスを有効期間の間使い続けないように、その場合はキャッシュしない
+	if failed {
+		return r.servers, nil
+	}
 
-			wait.Add(1)
+	cached = []*cachedVm{}
+	for _, vm := range r.servers {
+		cached = append(cached, newCachedVm(vm))
+	}
+	if err := cache.Set(key, cached); err != nil {
+		lib.GetLogInstance().Debugf("could not write cache: %s", err)
+	}
 
-			go func(vm *Vm) {
-				vm.ServerStatus, err = cmd.GetVMStatus(vm.Id)
-				if err != nil {
-					vm.ServerStatus = StatusUnknown
-				}
+	return r.servers, nil
+}
+
+// VPSの詳細を並行して取得する数のデフォルト値
+const DefaultParallel = 4
+
+// serversのそれぞれについてfnを並行して実行する
+// 同時に実行するのはparallel個までで、fnにはgoroutineごとにForkしたbrowserが渡される
+// fnの返したエラーをserversと同じ順番で返す
+func (cmd *Vps) eachVm(servers []*Vm, parallel int, fn func(browser *cpanel.Browser, vm *Vm) error) []error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	errs := make([]error, len(servers))
+	sem := make(chan struct{}, parallel)
+	wait := new(sync.WaitGroup)
+
+	for i, vm := range servers {
+		wait.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, vm *Vm) {
+			defer func() {
+				<-sem
 				wait.Done()
-			}(vm)
+			}()
+			errs[i] = fn(cmd.browser.Fork(), vm)
+		}(i, vm)
+	}
+
+	wait.Wait()
+	return errs
+}
 
-			wait.Wait()
+// 全VPSの詳細(IPアドレス、CPU、メモリ、収容ホストなど)を並行して取得してserversにセットする
+// 取得に失敗したVPSがあっても中断せず、VPSごとのエラーをserversと同じ順番で返す
+func (cmd *VpsList) Details(servers []*Vm, parallel int) []error {
+	cache := lib.GetCacheInstance()
+
+	return cmd.eachVm(servers, parallel, func(browser *cpanel.Browser, vm *Vm) error {
+		// 作成中のVPSはIDがないので詳細を取得できない
+		if vm.Id == "" {
+			return nil
 		}
 
-	} else {
-		for _, vm := range servers {
-			vm.ServerStatus = StatusNoinformation
+		key := "stat-" + vm.Id
+		cached := &cachedVm{}
+		if cmd.cacheGet(key, cached) {
+			// 一覧の項目はキャッシュより新しいので、詳細情報だけを使う
+			vm.mergeDetail(cached.vm())
+			return nil
 		}
-	}
 
-	return r.servers, nil
+		if err := fetchVmDetail(browser, vm); err != nil {
+			return err
+		}
+
+		if err := cache.Set(key, newCachedVm(vm)); err != nil {
+			lib.GetLogInstance().Debugf("could not write cache: %s", err)
+		}
+		return nil
+	})
 }
 
 // VPS一覧を取得するリクエスト
@@ -223,7 +410,7 @@ func (r *listResult) Populate(resp *http.Response, doc *goquery.Document) error
 		// TrIDを取得
 		trid, exists := tr.Attr("id")
 		if !exists {
-			return errors.New("TrID not exists")
+			return cpanel.NewMarkupError("TrID not exists")
 		}
 		vm.TrId = trid
 
@@ -290,6 +477,12 @@ type vmStatusResult struct {
 }
 
 func (cmd *Vps) GetVMStatus(id string) (status ServerStatus, err error) {
+	return getVMStatus(cmd.browser, id)
+}
+
+// browserを指定してVMのステータスを取得する
+// 並行して取得する場合はForkしたbrowserを渡すこと
+func getVMStatus(browser *cpanel.Browser, id string) (status ServerStatus, err error) {
 
 	if id == "" {
 		return StatusUnknown, nil
@@ -305,11 +498,11 @@ func (cmd *Vps) GetVMStatus(id string) (status ServerStatus, err error) {
 
 	values := url.Values{}
 	values.Add("evid", id)
-	cmd.browser.BrowserInfo.Values = values
+	browser.BrowserInfo.Values = values
 
-	cmd.browser.AddAction(act)
+	browser.AddAction(act)
 
-	if err = cmd.browser.Run(); err != nil {
+	if err = browser.Run(); err != nil {
 		return StatusUnknown, err
 	} else {
 		return r.Status, nil
@@ -338,17 +531,6 @@ func (r *vmStatusResult) Populate(resp *http.Response) error {
 		return err
 	}
 
-	switch j.StatusName {
-	case "Running":
-		r.Status = StatusRunning
-	case "Offline":
-		r.Status = StatusOffline
-	case "In-use":
-		r.Status = StatusInUse
-	case "In-formulation":
-		r.Status = StatusInFormulation
-	default:
-		r.Status = StatusUnknown
-	}
+	r.Status = parseServerStatus(j.StatusName, j.StatusId)
 	return nil
 }
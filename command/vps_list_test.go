@@ -0,0 +1,155 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"github.com/mattn/go-runewidth"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testListServers() []*Vm {
+	created := time.Date(2015, 1, 27, 13, 15, 0, 0, time.UTC)
+	return []*Vm{
+		{Id: "f648a6646b7e7d91", Label: "ウェブサーバー", Plan: "8GB Memory", ServerStatus: StatusRunning, ServiceStatus: "In operation", CreatedAt: created},
+		{Id: "a2ae45355615d641", Label: "db", Plan: "4GB Memory", ServerStatus: StatusOffline, ServiceStatus: "In operation", CreatedAt: created},
+	}
+}
+
+func TestVmListTableAlignment(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := lib.RenderTable(buf, vmListTable(testListServers(), false), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 全角文字を含む場合でもPlan列の開始位置が揃っていること
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	pos := -1
+	for _, line := range lines {
+		i := strings.Index(line, "GB Memory")
+		if i < 0 {
+			i = strings.Index(line, "Plan")
+		} else {
+			i--
+		}
+
+		w := runewidth.StringWidth(line[:i])
+		if pos >= 0 && w != pos {
+			t.Errorf("columns are not aligned.\n%s", buf.String())
+		}
+		pos = w
+	}
+
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("should not be colorized.\n%s", buf.String())
+	}
+}
+
+func TestVmListTableTruncate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &lib.TableOptions{MaxWidth: 110, FixedColumns: vmListFixedColumns, Color: true, CellColor: func(row int, col int) lib.Color {
+		return lib.ColorGreen
+	}}
+	if err := lib.RenderTable(buf, vmListTable(testListServers(), true), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		plain := strings.NewReplacer("\x1b[1m", "", "\x1b[32m", "", "\x1b[0m", "").Replace(line)
+		if w := runewidth.StringWidth(plain); w > 110 {
+			t.Errorf("line should be truncated to the width of terminal. [%d] %s", w, plain)
+		}
+	}
+}
+
+func TestCachedVm(t *testing.T) {
+	vm := &Vm{Id: "f648a6646b7e7d91", TrId: "ctl02", Label: "web-01"}
+
+	// 出力にはTrIdを含めない
+	out := &bytes.Buffer{}
+	if err := lib.Render(out, "json", []*Vm{vm}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "ctl02") {
+		t.Errorf("trId should not be in the output.\n%s", out.String())
+	}
+
+	// キャッシュにはTrIdを保存する
+	data, err := json.Marshal(newCachedVm(vm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := &cachedVm{}
+	if err = json.Unmarshal(data, cached); err != nil {
+		t.Fatal(err)
+	}
+	if got := cached.vm(); got.TrId != "ctl02" || got.Id != vm.Id || got.Label != vm.Label {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestVmMergeDetail(t *testing.T) {
+	deleteDate := time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)
+	vm := &Vm{Id: "f648a6646b7e7d91", Label: "web-02", ServiceStatus: "Suspended", DeleteDate: deleteDate, ServerStatus: StatusNoinformation}
+	detail := &Vm{Id: "f648a6646b7e7d91", Label: "web-01", ServiceStatus: "In operation", ServerStatus: StatusRunning, IPv4: "203.0.113.1", IPv6: []string{"2001:db8::1"}, House: "h01"}
+
+	vm.mergeDetail(detail)
+	if vm.Label != "web-02" || vm.ServiceStatus != "Suspended" || !vm.DeleteDate.Equal(deleteDate) {
+		t.Errorf("the list fields should not be overwritten. got %+v", vm)
+	}
+	if vm.IPv4 != "203.0.113.1" || len(vm.IPv6) != 1 || vm.House != "h01" || vm.ServerStatus != StatusRunning {
+		t.Errorf("the detail fields should be copied. got %+v", vm)
+	}
+
+	// 一覧で取得したステータスはそのままにする
+	vm.ServerStatus = StatusOffline
+	vm.mergeDetail(detail)
+	if vm.ServerStatus != StatusOffline {
+		t.Errorf("got %s", vm.ServerStatus)
+	}
+}
+
+func TestListCacheStatusError(t *testing.T) {
+	defer setupHome(t, `{"Account":"C12345678"}`)()
+	config := &lib.Config{}
+	config.Read()
+	cache := lib.GetCacheInstance()
+	cache.Configure(config)
+	defer cache.Configure(&lib.Config{})
+
+	transport := http.DefaultTransport
+	defer func() { http.DefaultTransport = transport }()
+
+	vpsList := &VpsList{Vps: &Vps{Command: &Command{browser: cpanel.NewBrowser().Fork()}, refresh: true}}
+	var cached []*cachedVm
+
+	// ステータスを取得できなかった場合はキャッシュしない
+	http.DefaultTransport = &fakePanel{failStatus: true}
+	servers, err := vpsList.List(true)
+	if err != nil || len(servers) != 2 || servers[0].ServerStatus != StatusUnknown {
+		t.Fatalf("got %v [%v]", servers, err)
+	}
+	if cache.Get("list-status", &cached) {
+		t.Errorf("unknown status should not be cached.")
+	}
+
+	http.DefaultTransport = &fakePanel{}
+	if _, err = vpsList.List(true); err != nil {
+		t.Fatal(err)
+	}
+	if !cache.Get("list-status", &cached) || len(cached) != 2 || cached[0].ServerStatus != StatusRunning {
+		t.Errorf("got %v", cached)
+	}
+
+	// 一時ファイルは残さない
+	dir, _ := config.ConfigDirPath()
+	files, _ := filepath.Glob(filepath.Join(dir, "cache", "C12345678", "*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "list-status.json" {
+		t.Errorf("got %v", files)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"net/http"
 	"net/url"
 	"os"
@@ -22,7 +21,7 @@ const (
 )
 
 type VpsPower struct {
-	vmId      string
+	vmIds     []string
 	command   string
 	forceSend bool
 
@@ -39,12 +38,11 @@ func (cmd *VpsPower) parseFlag() error {
 	var help bool
 	var command string
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.StringVarP(&command, "command", "c", "", "power command")
-	fs.BoolVarP(&cmd.forceSend, "force-send", "f", false, "force send")
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&command, "command", "c", "", "")
+	fs.BoolVarP(&cmd.forceSend, "force-send", "f", GetGlobalOptions().Yes, "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return err
@@ -57,7 +55,7 @@ func (cmd *VpsPower) parseFlag() error {
 
 	if command == "" {
 		fs.Usage()
-		return errors.New("Not enough arguments.")
+		return errors.New(lib.T("Not enough arguments."))
 	}
 
 	switch command {
@@ -70,56 +68,64 @@ func (cmd *VpsPower) parseFlag() error {
 	case "stop":
 		cmd.command = STOP
 	default:
-		return errors.New(fmt.Sprintf(`Undefined command "%s".`, command))
+		return errors.New(lib.T(`Undefined command "%s".`, command))
 	}
 
 	if len(fs.Args()) < 2 {
-		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける
-		vm, err := cmd.Vps.vpsSelectMenu()
+		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける(複数選択できる)
+		servers, err := cmd.Vps.vpsSelectMulti()
 		if err != nil {
 			return err
 		}
-		cmd.vmId = vm.Id
+		for _, vm := range servers {
+			cmd.vmIds = append(cmd.vmIds, vm.Id)
+		}
+
+	} else {
+		cmd.vmIds = []string{fs.Arg(1)}
 	}
 
 	return nil
 }
 
 func (cmd *VpsPower) Usage() {
-	fmt.Println(`Usage: conoha power <VPS-ID> [OPTIONS]
-
-DESCRIPTION
-    Send power-command to VPS.
-
-<VPS-ID> (Optional)VPS-ID to get the stats. It may be confirmed by LIST subcommand.
-         If not set, It will be selected with prompting for VPS list.
-
-OPTIONS
-    -c: --command:     Power command. It should be one of following.
-                       ("boot" "reboot" "shutdown" "stop")
-
-    -f: --force-send:  Attempt to send without prompting for confirmation.
-
-    -h: --help:        Show usage.
-`)
+	printUsage("power")
 }
 
 func (cmd *VpsPower) Run() error {
-	if err := cmd.parseFlag(); err != nil {
-		return err
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
 	}
 
-	return cmd.SendCommand(cmd.vmId, cmd.command)
+	// 複数のVPSが選択された場合は順番に送信し、エラーが起きたらそこで中止する
+	for _, vmId := range cmd.vmIds {
+		if vmId, err = cmd.resolveVmId(vmId); err != nil {
+			return err
+		}
+		lib.AddLogField("vps_id", vmId)
+
+		record := cmd.newAuditRecord(lib.AuditPower, vmId)
+		record.Params = map[string]string{"command": cmd.command, "force-send": strconv.FormatBool(cmd.forceSend)}
+		record.Confirmation = auditConfirmation(cmd.forceSend)
+
+		err = cmd.SendCommand(vmId, cmd.command)
+		cmd.audit(record, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // 電源の状態を変更するコマンドを送信する
 func (cmd *VpsPower) SendCommand(vmId string, command string) error {
 
 	// 対象のVMを特定する
-	vpsList := NewVpsList()
+	vpsList := &VpsList{Vps: cmd.Vps}
 	vm := vpsList.Vm(vmId)
 	if vm == nil {
-		return errors.New(fmt.Sprintf("VPS not found(id=%s).", vmId))
+		return &VpsNotFoundError{VmId: vmId}
 	}
 
 	// VPSのステータスを取得する
@@ -127,17 +133,17 @@ func (cmd *VpsPower) SendCommand(vmId string, command string) error {
 
 	// BOOTコマンドは停止中のVPSにのみ送信できる
 	if command == BOOT && stat != StatusOffline {
-		return errors.New(fmt.Sprintf(`Could not send "%s" command. VPS is already running.`, command))
+		return errors.New(lib.T(`Could not send "%s" command. VPS is already running.`, command))
 
 		// それ以外のコマンドは稼働中のVPSにのみ送信できる
 	} else if command != BOOT && stat != StatusRunning {
-		return errors.New(fmt.Sprintf(`Could not send "%s" command.  VPS might be offiline.`, command))
+		return errors.New(lib.T(`Could not send "%s" command.  VPS might be offiline.`, command))
 	}
 
 	// 確認ダイアログ
 	if !cmd.forceSend {
 		if !cmd.confirmation(vm, command) {
-			return nil
+			return &DeclinedError{}
 		}
 	}
 
@@ -155,12 +161,15 @@ func (cmd *VpsPower) SendCommand(vmId string, command string) error {
 
 	cmd.browser.AddAction(act)
 
+	// VPSのステータスが変わるのでキャッシュを削除する
+	defer cmd.clearCache()
+
 	if err = cmd.browser.Run(); err != nil {
-		return err
+		return showDryRun(err)
 	}
 
 	log := lib.GetLogInstance()
-	log.Infof(`"%s" command was sent to VPS(id=%s).`, command, vmId)
+	log.Info(lib.T(`"%s" command was sent to VPS(id=%s).`, command, vmId))
 
 	return nil
 }
@@ -168,8 +177,7 @@ func (cmd *VpsPower) SendCommand(vmId string, command string) error {
 // 確認ダイアログ
 func (cmd *VpsPower) confirmation(vm *Vm, command string) bool {
 
-	fmt.Printf(`Send "%s" command to VPS(Label=%s). Are you sure?`, command, vm.Label)
-	fmt.Println("")
+	fmt.Println(lib.T(`Send "%s" command to VPS(Label=%s). Are you sure?`, command, vm.Label))
 	fmt.Print("[y/N]: ")
 
 	var no string
@@ -189,6 +197,9 @@ type vpsPowerRequest struct {
 	command string
 }
 
+// 電源を操作するのでドライランでは送信しない
+func (r *vpsPowerRequest) Irreversible() {}
+
 func (r *vpsPowerRequest) NewRequest(values url.Values) (*http.Request, error) {
 	values = url.Values{}
 	values.Add("command", r.command)
@@ -209,7 +220,7 @@ type vpsPowerResult struct {
 func (r *vpsPowerResult) Populate(resp *http.Response) error {
 
 	if resp.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("Server returned the errror status code(%d).", resp.StatusCode))
+		return errors.New(lib.T("Server returned the errror status code(%d).", resp.StatusCode))
 	}
 
 	return nil
@@ -9,15 +9,15 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
 	"github.com/hironobu-s/conoha-vps/lib"
-	flag "github.com/ogier/pflag"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 )
 
 type VpsRemove struct {
-	vmId        string
+	vmIds       []string
 	forceRemove bool
 	*Vps
 }
@@ -31,11 +31,10 @@ func NewVpsRemove() *VpsRemove {
 func (cmd *VpsRemove) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.BoolVarP(&cmd.forceRemove, "force-remove", "f", false, "force remove.")
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.BoolVarP(&cmd.forceRemove, "force-remove", "f", GetGlobalOptions().Yes, "")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
@@ -48,43 +47,47 @@ func (cmd *VpsRemove) parseFlag() error {
 	}
 
 	if len(fs.Args()) < 2 {
-		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける
-		vm, err := cmd.Vps.vpsSelectMenu()
+		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける(複数選択できる)
+		servers, err := cmd.Vps.vpsSelectMulti()
 		if err != nil {
 			return err
 		}
-		cmd.vmId = vm.Id
+		for _, vm := range servers {
+			cmd.vmIds = append(cmd.vmIds, vm.Id)
+		}
 
 	} else {
-		cmd.vmId = os.Args[2]
+		cmd.vmIds = []string{fs.Arg(1)}
 	}
 	return nil
 }
 
 func (cd *VpsRemove) Usage() {
-	fmt.Println(`Usage: conoha stat <VPS-ID> [OPTIONS]
-
-DESCRIPTION
-    Remove VPS.
-
-<VPS-ID> (Optional)VPS-ID to get the stats. It may be confirmed by LIST subcommand.
-         If not set, It will be selected with prompting for VPS list.
-
-OPTIONS
-    -h: --help:          Show usage.
-    -f: --force-remove:  Attempt to remove the VPS without prompting for confirmation.
-`)
+	printUsage("remove")
 }
 
 func (cmd *VpsRemove) Run() error {
 	var err error
 	if err = cmd.parseFlag(); err != nil {
-		return err
+		return usageError(err)
 	}
 
-	err = cmd.Remove(cmd.vmId)
-	if err != nil {
-		return err
+	// 複数のVPSが選択された場合は順番に削除し、エラーが起きたらそこで中止する
+	for _, vmId := range cmd.vmIds {
+		if vmId, err = cmd.resolveVmId(vmId); err != nil {
+			return err
+		}
+		lib.AddLogField("vps_id", vmId)
+
+		record := cmd.newAuditRecord(lib.AuditRemove, vmId)
+		record.Params = map[string]string{"force-remove": strconv.FormatBool(cmd.forceRemove)}
+		record.Confirmation = auditConfirmation(cmd.forceRemove)
+
+		err = cmd.Remove(vmId)
+		cmd.audit(record, err)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -94,17 +97,16 @@ func (cmd *VpsRemove) Remove(vmId string) error {
 	log := lib.GetLogInstance()
 
 	// 削除対象のVMを特定する
-	vpsList := NewVpsList()
+	vpsList := &VpsList{Vps: cmd.Vps}
 	vm := vpsList.Vm(vmId)
 	if vm == nil {
-		msg := fmt.Sprintf("VPS not found(id=%s).", vmId)
-		return errors.New(msg)
+		return &VpsNotFoundError{VmId: vmId}
 	}
 
 	// 削除確認
 	if !cmd.forceRemove {
 		if !cmd.confirmationRemove(vm) {
-			return nil
+			return &DeclinedError{}
 		}
 	}
 
@@ -130,11 +132,14 @@ func (cmd *VpsRemove) Remove(vmId string) error {
 	}
 	cmd.browser.AddAction(act)
 
+	// VPSの一覧が変わるのでキャッシュを削除する
+	defer cmd.clearCache()
+
 	if err := cmd.browser.Run(); err != nil {
-		return err
+		return showDryRun(err)
 	}
 
-	log.Infof("Removing VPS is complete.")
+	log.Info(lib.T("Removing VPS is complete."))
 
 	return nil
 }
@@ -142,7 +147,7 @@ func (cmd *VpsRemove) Remove(vmId string) error {
 // 削除確認ダイアログ
 func (cmd *VpsRemove) confirmationRemove(vm *Vm) bool {
 
-	fmt.Printf("Remove VPS[Label=%s]. Are you sure?\n", vm.Label)
+	fmt.Println(lib.T("Remove VPS[Label=%s]. Are you sure?", vm.Label))
 	fmt.Print("[y/N]: ")
 
 	var no string
@@ -191,7 +196,7 @@ func (r *removeFormResult) Populate(resp *http.Response, doc *goquery.Document)
 	sel := doc.Find("#ContentPlaceHolder1_ContentPlaceHolder1_btnConfirm")
 	v, _ := sel.Attr("value")
 	if v == "" {
-		return errors.New("Server returned the invalid body(Confirm button is not included).")
+		return cpanel.NewMarkupError("Server returned the invalid body(Confirm button is not included).")
 	}
 	return nil
 }
@@ -221,7 +226,7 @@ func (r *removeConfirmResult) Populate(resp *http.Response, doc *goquery.Documen
 	sel := doc.Find("#ContentPlaceHolder1_ContentPlaceHolder1_btnConfirm")
 	v, _ := sel.Attr("value")
 	if v == "" {
-		return errors.New("Server returned the invalid body(Submit button is not included).")
+		return cpanel.NewMarkupError("Server returned the invalid body(Submit button is not included).")
 	}
 	return nil
 }
@@ -230,6 +235,9 @@ func (r *removeConfirmResult) Populate(resp *http.Response, doc *goquery.Documen
 
 type removeSubmitRequest struct{}
 
+// VPSを削除するのでドライランでは送信しない
+func (r *removeSubmitRequest) Irreversible() {}
+
 func (r *removeSubmitRequest) NewRequest(values url.Values) (*http.Request, error) {
 	values.Set("ctl00$ctl00$ContentPlaceHolder1$ContentPlaceHolder1$btnConfirm", "決定")
 
@@ -0,0 +1,114 @@
+package command
+
+// VPSの指定を解決する
+// VPS-IDの代わりに、ラベル、VPS-IDの前方一致、サービスID、IPアドレスでも指定できる
+
+import (
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"net"
+	"strings"
+)
+
+// 指定に複数のVPSが一致した場合のエラー
+type AmbiguousVpsError struct {
+	Ref        string
+	Candidates []*Vm
+}
+
+func (e *AmbiguousVpsError) Error() string {
+	lines := []string{lib.T(`"%s" matches %d VPS. Specify one of the following.`, e.Ref, len(e.Candidates))}
+	for _, vm := range e.Candidates {
+		lines = append(lines, fmt.Sprintf("    %s  %s  %s", vm.Id, lib.PadRight(vm.Label, 20), vm.ServiceId))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *AmbiguousVpsError) ExitCode() ExitCode {
+	return ExitCodeUsage
+}
+
+// 指定(ID、ラベル、IDの前方一致、サービスID、IPアドレス)からVPSを探す
+// IPアドレスは詳細にしかないので、IPアドレスが指定された場合だけ全VPSの詳細を取得する(キャッシュがあればそれを使う)
+func (cmd *VpsList) Resolve(ref string) (*Vm, error) {
+	servers, err := cmd.List(false)
+	if err != nil {
+		return nil, requestError(err)
+	}
+
+	if parseVmAddress(ref) != nil {
+		for i, err := range cmd.Details(servers, DefaultParallel) {
+			if err != nil {
+				lib.GetLogInstance().Debugf("could not get the details of VPS(id=%s): %s", servers[i].Id, err)
+			}
+		}
+	}
+
+	return resolveVm(servers, ref)
+}
+
+// 指定をVPS-IDに変換する
+func (cmd *Vps) resolveVmId(ref string) (string, error) {
+	vm, err := (&VpsList{Vps: cmd}).Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	return vm.Id, nil
+}
+
+// serversから指定に一致するVPSを探す
+// VPS-ID、サービスID、IPアドレス、ラベル、VPS-IDの前方一致の順番で比較して、最初に一致したものを返す
+// 同じ順位で複数のVPSが一致した場合はエラーにする
+func resolveVm(servers []*Vm, ref string) (*Vm, error) {
+	if ref == "" {
+		return nil, &VpsNotFoundError{}
+	}
+
+	ip := parseVmAddress(ref)
+	matchers := []func(vm *Vm) bool{
+		func(vm *Vm) bool { return vm.Id == ref },
+		func(vm *Vm) bool { return vm.ServiceId == ref },
+		func(vm *Vm) bool { return ip != nil && vmHasAddress(vm, ip) },
+		func(vm *Vm) bool { return vm.Label == ref },
+		func(vm *Vm) bool { return vm.Id != "" && strings.HasPrefix(vm.Id, ref) },
+	}
+
+	for _, match := range matchers {
+		candidates := []*Vm{}
+		for _, vm := range servers {
+			if match(vm) {
+				candidates = append(candidates, vm)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return nil, &AmbiguousVpsError{Ref: ref, Candidates: candidates}
+		}
+	}
+	return nil, &VpsNotFoundError{VmId: ref}
+}
+
+// IPアドレスをパースする。"2001:db8::1/64" のようなプレフィックス付きでもよい
+// IPアドレスでない場合はnilを返す
+func parseVmAddress(s string) net.IP {
+	if i := strings.Index(s, "/"); i >= 0 {
+		s = s[:i]
+	}
+	return net.ParseIP(strings.TrimSpace(s))
+}
+
+// VPSのIPv4アドレスかIPv6アドレスのどれかがipと一致するか
+func vmHasAddress(vm *Vm, ip net.IP) bool {
+	addrs := append([]string{vm.IPv4}, vm.IPv6...)
+	for _, addr := range addrs {
+		if a := parseVmAddress(addr); a != nil && a.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
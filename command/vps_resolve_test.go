@@ -0,0 +1,48 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestResolveVm(t *testing.T) {
+	servers := []*Vm{
+		{Id: "f648a6646b7e7d91", Label: "web", ServiceId: "VPS00712701", IPv4: "157.7.0.1", IPv6: []string{"2400:8500:1301::1"}},
+		{Id: "f6c1d7e05fd3f3c6", Label: "db", ServiceId: "VPS00712702", IPv4: "157.7.0.2"},
+		{Id: "0c1d7e05fd3f3c6a", Label: "web", ServiceId: "VPS00712703"},
+		{Id: "9b2e0a1c3d4e5f60", Label: "f648a6646b7e7d91", ServiceId: "VPS00712704"},
+	}
+
+	tests := map[string]string{
+		"f648a6646b7e7d91":     "f648a6646b7e7d91", // IDはラベルより優先する
+		"f6c":                  "f6c1d7e05fd3f3c6",
+		"db":                   "f6c1d7e05fd3f3c6",
+		"VPS00712703":          "0c1d7e05fd3f3c6a",
+		"157.7.0.2":            "f6c1d7e05fd3f3c6",
+		"2400:8500:1301:0::1":  "f648a6646b7e7d91",
+		"2400:8500:1301::1/64": "f648a6646b7e7d91",
+		"9b2e0a1c3d4e5f60":     "9b2e0a1c3d4e5f60",
+	}
+	for ref, want := range tests {
+		vm, err := resolveVm(servers, ref)
+		if err != nil {
+			t.Errorf("%s: %s", ref, err)
+		} else if vm.Id != want {
+			t.Errorf("%s: got %s, want %s", ref, vm.Id, want)
+		}
+	}
+
+	// ラベルや前方一致が複数のVPSに一致する場合は候補を示してエラーにする
+	for _, ref := range []string{"web", "f6"} {
+		_, err := resolveVm(servers, ref)
+		e, ok := err.(*AmbiguousVpsError)
+		if !ok || len(e.Candidates) != 2 {
+			t.Errorf("%s: should be ambiguous. [%v]", ref, err)
+		}
+	}
+
+	for _, ref := range []string{"", "mail", "157.7.0.3"} {
+		if _, err := resolveVm(servers, ref); ExitCodeOf(err) != ExitCodeNotFound {
+			t.Errorf("%s: should not be found. [%v]", ref, err)
+		}
+	}
+}
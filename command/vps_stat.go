@@ -1,16 +1,14 @@
 package command
 
 import (
-	"errors"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hironobu-s/conoha-vps/cpanel"
-	flag "github.com/ogier/pflag"
+	"github.com/hironobu-s/conoha-vps/lib"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -30,22 +28,29 @@ func NewVpsStat() *VpsStat {
 func (cmd *VpsStat) parseFlag() error {
 	var help bool
 
-	fs := flag.NewFlagSet("conoha-vps", flag.ContinueOnError)
-	fs.Usage = cmd.Usage
+	fs := newFlagSet(cmd.Usage)
 
-	fs.BoolVarP(&help, "help", "h", false, "help")
-	fs.BoolVarP(&cmd.incIPv6, "include-ipv6", "6", false, "Including IPv6 informations.")
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.BoolVarP(&cmd.incIPv6, "include-ipv6", "6", false, "")
+	cmd.addCacheFlags(fs)
+	cmd.addOutputFlag(fs)
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fs.Usage()
 		return err
 	}
+	cmd.applyCacheFlags()
 
 	if help {
 		fs.Usage()
 		return &ShowUsageError{}
 	}
 
+	if err := cmd.validateOutputFlag(); err != nil {
+		fs.Usage()
+		return err
+	}
+
 	if len(fs.Args()) < 2 {
 		// コマンドライン引数で指定されていない場合は、標準入力から受け付ける
 		vm, err := cmd.Vps.vpsSelectMenu()
@@ -55,105 +60,135 @@ func (cmd *VpsStat) parseFlag() error {
 		cmd.vmId = vm.Id
 
 	} else {
-		cmd.vmId = os.Args[2]
+		cmd.vmId = fs.Arg(1)
 	}
 	return nil
 }
 
 func (cd *VpsStat) Usage() {
-	fmt.Println(`Usage: conoha stat <VPS-ID> [OPTIONS]
-
-DESCRIPTION
-    Show VPS stats.
-
-<VPS-ID> (Optional)VPS-ID to get the stats. It may be confirmed by LIST subcommand.
-         If not set, It will be selected from the list menu.
-
-OPTIONS
-    -h: --help:         Show usage.      
-    -6 --include-ipv6:  Include IPv6 informations in output.
-`)
+	printUsage("stat")
 }
 
 func (cmd *VpsStat) Run() error {
 	var err error
 	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+	if cmd.vmId, err = cmd.resolveVmId(cmd.vmId); err != nil {
 		return err
 	}
+	lib.AddLogField("vps_id", cmd.vmId)
 
 	vm, err := cmd.Stat(cmd.vmId)
 	if err != nil {
 		return err
 	}
 
-	var lines []string = []string{}
+	if cmd.template != nil {
+		return lib.RenderTemplate(os.Stdout, cmd.template, vm)
+	}
+
+	if cmd.output != lib.OutputTable {
+		return lib.Render(os.Stdout, cmd.output, vm, vmTable([]*Vm{vm}, nil))
+	}
 
+	// 項目名は翻訳すると全角文字になるので、表示幅で揃える
 	padding := 20
-	format := "%-" + strconv.Itoa(padding) + "s %s"
+	lines := []string{}
+	for _, field := range vmStatFields(vm, cmd.incIPv6) {
+		lines = append(lines, fmt.Sprintf("%s %v", lib.PadRight(lib.T(field[0]), padding), field[1]))
+	}
+
+	fmt.Println(strings.Join(lines, "\n"))
+
+	return nil
+}
+
+// statで表示する項目名(翻訳前)と値
+// ipv6がtrueの場合はIPv6の情報も含める
+func vmStatFields(vm *Vm, ipv6 bool) [][]string {
+	fields := [][]string{}
+	add := func(name string, value interface{}) {
+		fields = append(fields, []string{name, fmt.Sprint(value)})
+	}
 
-	lines = append(lines, fmt.Sprintf(format, "VPS ID", vm.Id))
-	lines = append(lines, fmt.Sprintf(format, "ServerStatus", vm.ServerStatus))
-	lines = append(lines, fmt.Sprintf(format, "Label", vm.Label))
-	lines = append(lines, fmt.Sprintf(format, "ServiceStatus", vm.ServiceStatus))
-	lines = append(lines, fmt.Sprintf(format, "Service ID", vm.ServiceId))
-	lines = append(lines, fmt.Sprintf(format, "Plan", vm.Plan))
-	lines = append(lines, fmt.Sprintf(format, "Created At", vm.CreatedAt.Format(time.RFC3339)))
+	add("VPS ID", vm.Id)
+	add("ServerStatus", vm.ServerStatus)
+	add("Label", vm.Label)
+	add("ServiceStatus", vm.ServiceStatus)
+	add("Service ID", vm.ServiceId)
+	add("Plan", vm.Plan)
+	add("Created At", vm.CreatedAt.Format(time.RFC3339))
 
 	if !vm.DeleteDate.IsZero() {
-		lines = append(lines, fmt.Sprintf(format, "Delete Date", vm.DeleteDate))
+		add("Delete Date", vm.DeleteDate)
 	} else {
-		lines = append(lines, fmt.Sprintf(format, "Delete Date", "-"))
+		add("Delete Date", "-")
 	}
 
-	lines = append(lines, fmt.Sprintf(format, "Payment Span", vm.PaymentSpan))
-	lines = append(lines, fmt.Sprintf(format, "CPU", vm.NumCpuCore))
-	lines = append(lines, fmt.Sprintf(format, "Memory", vm.Memory))
-	lines = append(lines, fmt.Sprintf(format, "Disk1", vm.Disk1Size))
-	lines = append(lines, fmt.Sprintf(format, "Disk2", vm.Disk2Size))
+	add("Payment Span", vm.PaymentSpan)
+	add("CPU", vm.NumCpuCore)
+	add("Memory", vm.Memory)
+	add("Disk1", vm.Disk1Size)
+	add("Disk2", vm.Disk2Size)
 
-	lines = append(lines, fmt.Sprintf(format, "IPv4 Address", vm.IPv4))
-	lines = append(lines, fmt.Sprintf(format, "IPv4 Netmask", vm.IPv4netmask))
-	lines = append(lines, fmt.Sprintf(format, "IPv4 Gateway", vm.IPv4gateway))
-	lines = append(lines, fmt.Sprintf(format, "IPv4 DNS1", vm.IPv4dns1))
-	lines = append(lines, fmt.Sprintf(format, "IPv4 DNS2", vm.IPv4dns2))
+	add("IPv4 Address", vm.IPv4)
+	add("IPv4 Netmask", vm.IPv4netmask)
+	add("IPv4 Gateway", vm.IPv4gateway)
+	add("IPv4 DNS1", vm.IPv4dns1)
+	add("IPv4 DNS2", vm.IPv4dns2)
 
-	if cmd.incIPv6 {
+	if ipv6 {
 		for i := 0; i < len(vm.IPv6); i++ {
 			if i == 0 {
-				lines = append(lines, fmt.Sprintf(format, "IPv6 Address", vm.IPv6[i]))
+				add("IPv6 Address", vm.IPv6[i])
 			} else {
-				lines = append(lines, fmt.Sprintf(format, "", vm.IPv6[i]))
+				add("", vm.IPv6[i])
 			}
 		}
-		lines = append(lines, fmt.Sprintf(format, "IPv6 Gateway", vm.IPv6gateway))
-		lines = append(lines, fmt.Sprintf(format, "IPv6 DNS1", vm.IPv6dns1))
-		lines = append(lines, fmt.Sprintf(format, "IPv6 DNS2", vm.IPv6dns2))
+		add("IPv6 Gateway", vm.IPv6gateway)
+		add("IPv6 DNS1", vm.IPv6dns1)
+		add("IPv6 DNS2", vm.IPv6dns2)
 	}
 
-	lines = append(lines, fmt.Sprintf(format, "Host Server", vm.House))
-	lines = append(lines, fmt.Sprintf(format, "Common Server ID", vm.CommonServerId))
-	lines = append(lines, fmt.Sprintf(format, "Serial Console(SSH)", vm.SerialConsoleHost))
-	lines = append(lines, fmt.Sprintf(format, "ISO Upload(SFTP)", vm.IsoUploadHost))
-
-	fmt.Println(strings.Join(lines, "\n"))
-
-	return nil
+	add("Host Server", vm.House)
+	add("Common Server ID", vm.CommonServerId)
+	add("Serial Console(SSH)", vm.SerialConsoleHost)
+	add("ISO Upload(SFTP)", vm.IsoUploadHost)
+	return fields
 }
 
 // Vmの詳細を取得する
 func (cmd *VpsStat) Stat(vmId string) (*Vm, error) {
-	vpsList := NewVpsList()
+	// キャッシュが有効であればそれを返す
+	cache := lib.GetCacheInstance()
+	key := "stat-" + vmId
+
+	cached := &cachedVm{}
+	if vmId != "" && cmd.cacheGet(key, cached) {
+		return cached.vm(), nil
+	}
+
+	vpsList := &VpsList{Vps: cmd.Vps}
 	vm := vpsList.Vm(vmId)
 	if vm == nil {
-		var msg string
-		if vmId == "" {
-			msg = fmt.Sprintf("VPS not found.")
-		} else {
-			msg = fmt.Sprintf("VPS not found(id=%s).", vmId)
-		}
-		return nil, errors.New(msg)
+		return nil, &VpsNotFoundError{VmId: vmId}
 	}
 
+	if err := fetchVmDetail(cmd.browser, vm); err != nil {
+		return nil, err
+	}
+
+	if err := cache.Set(key, newCachedVm(vm)); err != nil {
+		lib.GetLogInstance().Debugf("could not write cache: %s", err)
+	}
+
+	return vm, nil
+}
+
+// VPSの詳細ページとステータスを取得してvmにセットする
+// 並行して取得する場合はForkしたbrowserを渡すこと
+func fetchVmDetail(browser *cpanel.Browser, vm *Vm) error {
 	act := &cpanel.Action{
 		Request: &statRequest{
 			vm: vm,
@@ -163,18 +198,17 @@ func (cmd *VpsStat) Stat(vmId string) (*Vm, error) {
 		},
 	}
 
-	cmd.browser.AddAction(act)
-	if err := cmd.browser.Run(); err != nil {
-		return nil, err
+	browser.AddAction(act)
+	if err := browser.Run(); err != nil {
+		return err
 	}
 
-	status, err := cmd.GetVMStatus(vm.Id)
+	status, err := getVMStatus(browser, vm.Id)
 	if err != nil {
-		return vm, err
+		return err
 	}
 	vm.ServerStatus = status
-
-	return vm, nil
+	return nil
 }
 
 type statRequest struct {
@@ -284,7 +318,7 @@ func (r *statResult) populateDate(doc *goquery.Document) error {
 		// 日付が未定。何もしない
 	} else {
 		// パースエラー
-		return errors.New("Parse error. Can't detect CreatedAt.")
+		return cpanel.NewMarkupError("Parse error. Can't detect CreatedAt.")
 	}
 
 	// 削除予定日
@@ -302,7 +336,7 @@ func (r *statResult) populateDate(doc *goquery.Document) error {
 		// 日付が未定。何もしない
 	} else {
 		// パースエラー
-		return errors.New("Parse error. Can't detect DeleteDate.")
+		return cpanel.NewMarkupError("Parse error. Can't detect DeleteDate.")
 	}
 	return nil
 }
@@ -315,7 +349,7 @@ func (r *statResult) populateUploadHosts(doc *goquery.Document) error {
 	matches := reg.FindAllStringSubmatch(body, -1)
 	if len(matches) == 0 {
 		// パースエラー
-		return errors.New("Parse error. Can't detect ISO upload host or serial console host.")
+		return cpanel.NewMarkupError("Parse error. Can't detect ISO upload host or serial console host.")
 	}
 
 	for i := 0; i < len(matches); i++ {
@@ -325,7 +359,7 @@ func (r *statResult) populateUploadHosts(doc *goquery.Document) error {
 			r.vm.IsoUploadHost = matches[i][1]
 		} else {
 			// パースエラー
-			return errors.New("Parse error. Can't detect ISO upload host or serial console host.")
+			return cpanel.NewMarkupError("Parse error. Can't detect ISO upload host or serial console host.")
 		}
 	}
 	return nil
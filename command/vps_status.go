@@ -0,0 +1,337 @@
+package command
+
+// VPSのステータス(ServerStatus)と、その状態遷移を扱う
+
+import (
+	"encoding/json"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"strconv"
+	"time"
+)
+
+// VPSのステータス
+// 値はGetVMStatus.aspxが返すstatus_idと同じ
+type ServerStatus int
+
+const (
+	StatusRunning       = 1  // 稼働中
+	StatusOffline       = 4  // 停止
+	StatusInUse         = 6  // 取得中
+	StatusInFormulation = 8  // サービス準備中
+	StatusNoinformation = 98 // 未取得
+	StatusUnknown       = 99
+)
+
+// コントロールパネルが返すstatus_nameとServerStatusの対応
+// コントロールパネルが返すことを確認できた値だけを定義する。それ以外はStatusUnknownになる
+var serverStatusNames = map[string]ServerStatus{
+	"Running":        StatusRunning,
+	"Offline":        StatusOffline,
+	"In-use":         StatusInUse,
+	"In-formulation": StatusInFormulation,
+}
+
+// 状態遷移表
+// キーのステータスから遷移しうるステータスの一覧。
+// 取得中(In-use)はどのステータスからも遷移しうるので、ここには含めない。
+var serverStatusTransitions = map[ServerStatus][]ServerStatus{
+	StatusRunning:       {StatusOffline},
+	StatusOffline:       {StatusRunning},
+	StatusInFormulation: {StatusRunning, StatusOffline},
+}
+
+func (s ServerStatus) String() string {
+	switch s {
+	case StatusRunning:
+		return lib.T("Running")
+	case StatusOffline:
+		return lib.T("Offline")
+	case StatusInUse:
+		return lib.T("No status")
+	case StatusInFormulation:
+		return lib.T("Preparing")
+	case StatusNoinformation:
+		return lib.T("-")
+	case StatusUnknown:
+		fallthrough
+	default:
+		return lib.T("Unknown")
+	}
+}
+
+// JSONなどで出力する場合のステータス名
+// String()と違い表示用ではないので変更しないこと
+func (s ServerStatus) Name() string {
+	for name, status := range serverStatusNames {
+		if status == s {
+			return name
+		}
+	}
+
+	if s == StatusNoinformation {
+		return "No-information"
+	}
+	return "Unknown"
+}
+
+// JSONではステータス名で出力する
+func (s ServerStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Name())
+}
+
+// 古いキャッシュなどのために、数値も受け付ける
+func (s *ServerStatus) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*s = ServerStatus(n)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	if name == "No-information" {
+		*s = StatusNoinformation
+	} else {
+		*s = parseServerStatus(name, "")
+	}
+	return nil
+}
+
+// YAMLでもステータス名で出力する
+func (s ServerStatus) MarshalYAML() (interface{}, error) {
+	return s.Name(), nil
+}
+
+// コントロールパネルのstatus_nameとstatus_idからServerStatusを決定する
+// status_nameが未知の場合はstatus_idで判定する
+func parseServerStatus(name string, id string) ServerStatus {
+	if s, ok := serverStatusNames[name]; ok {
+		return s
+	}
+
+	n, err := strconv.Atoi(id)
+	if err == nil {
+		s := ServerStatus(n)
+		if _, ok := serverStatusTransitions[s]; ok || s == StatusInUse {
+			return s
+		}
+	}
+	return StatusUnknown
+}
+
+// 状態が一時的なもの(時間経過で別の状態に遷移する)であればtrueを返す
+func (s ServerStatus) IsTransient() bool {
+	switch s {
+	case StatusInUse, StatusInFormulation:
+		return true
+	default:
+		return false
+	}
+}
+
+// 一覧の表で表示する色
+func (s ServerStatus) Color() lib.Color {
+	switch {
+	case s == StatusRunning:
+		return lib.ColorGreen
+	case s.IsTransient():
+		return lib.ColorYellow
+	case s == StatusOffline:
+		return lib.ColorGray
+	default:
+		return lib.ColorNone
+	}
+}
+
+// 状態遷移表上で、sからtへ直接遷移できる場合にtrueを返す
+func (s ServerStatus) CanTransitionTo(t ServerStatus) bool {
+	if s == t || s == StatusInUse || t == StatusInUse {
+		return true
+	}
+
+	for _, next := range serverStatusTransitions[s] {
+		if next == t {
+			return true
+		}
+	}
+	return false
+}
+
+// 状態遷移表上で、sからtへ(いくつかの状態を経由して)到達できる場合にtrueを返す
+// 状態が分からない場合は到達できるものとみなす
+func (s ServerStatus) CanReach(t ServerStatus) bool {
+	if _, ok := serverStatusTransitions[s]; !ok {
+		return true
+	}
+
+	visited := map[ServerStatus]bool{s: true}
+	queue := []ServerStatus{s}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == t {
+			return true
+		}
+
+		for _, next := range serverStatusTransitions[cur] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// --------------------------------
+
+const (
+	DefaultWaitTimeout  = 10 * time.Minute
+	DefaultWaitInterval = 5 * time.Second
+)
+
+// WaitForStatus()のオプション
+type WaitOptions struct {
+	// タイムアウト。0の場合はDefaultWaitTimeout
+	Timeout time.Duration
+
+	// ステータスを取得する間隔。0の場合はDefaultWaitInterval
+	Interval time.Duration
+
+	// ステータスを取得するたびに呼ばれる関数(nilでも良い)
+	Progress func(vmId string, status ServerStatus, elapsed time.Duration)
+}
+
+// WaitForStatus()がタイムアウトした場合のエラー
+type WaitTimeoutError struct {
+	VmId    string
+	Status  ServerStatus
+	Timeout time.Duration
+}
+
+func (e *WaitTimeoutError) Error() string {
+	msg := lib.T("Timed out after %s waiting for VPS(id=%s).", e.Timeout, e.VmId)
+	if e.Status != StatusNoinformation {
+		msg += lib.T(` Last status is "%s".`, e.Status)
+	}
+	return msg
+}
+
+func (e *WaitTimeoutError) ExitCode() ExitCode {
+	return ExitCodeTimeout
+}
+
+// 目的のステータスに到達できない状態になった場合のエラー
+type WaitFailedError struct {
+	VmId   string
+	Status ServerStatus
+	Target ServerStatus
+}
+
+func (e *WaitFailedError) Error() string {
+	return lib.T(`VPS(id=%s) can not become "%s" from "%s".`, e.VmId, e.Target, e.Status)
+}
+
+func (e *WaitFailedError) ExitCode() ExitCode {
+	return ExitCodeNG
+}
+
+// VPSのステータスが目的のステータスになるまで待つ
+// 状態遷移表上で目的のステータスに到達できなくなった場合は、待たずにWaitFailedErrorを返す。
+// 最後に取得したステータスを返す。
+func (cmd *Vps) WaitForStatus(vmId string, target ServerStatus, opts *WaitOptions) (status ServerStatus, err error) {
+	log := lib.GetLogInstance()
+
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	status = StatusNoinformation
+
+	for {
+		current, err := cmd.GetVMStatus(vmId)
+		if err != nil {
+			// VPSが削除された場合は待たずにエラーにする
+			if exists, e := cmd.vmExists(vmId); e == nil && !exists {
+				return status, &VpsNotFoundError{VmId: vmId}
+			}
+
+			// 一時的なエラーの可能性があるので、タイムアウトまで再試行する
+			log.Debugf("GetVMStatus failed(id=%s): %s", vmId, err)
+		} else {
+			status = current
+
+			if opts.Progress != nil {
+				opts.Progress(vmId, status, time.Since(start))
+			}
+
+			if status == target {
+				return status, nil
+			}
+
+			if !status.CanReach(target) {
+				return status, &WaitFailedError{
+					VmId:   vmId,
+					Status: status,
+					Target: target,
+				}
+			}
+		}
+
+		if !sleepUntil(interval, deadline) {
+			return status, &WaitTimeoutError{
+				VmId:    vmId,
+				Status:  status,
+				Timeout: timeout,
+			}
+		}
+	}
+}
+
+// intervalだけ待つ。deadlineを過ぎる場合はdeadlineまで待つ
+// 既にdeadlineを過ぎている場合は待たずにfalseを返す
+func sleepUntil(interval time.Duration, deadline time.Time) bool {
+	remaining := deadline.Sub(time.Now())
+	if remaining <= 0 {
+		return false
+	}
+
+	if remaining < interval {
+		interval = remaining
+	}
+	time.Sleep(interval)
+	return true
+}
+
+// VPSが一覧に存在するかを返す
+// VpsList.Vm()は通信エラーとVPSが存在しない場合を区別できないので、ここでは使わない
+// 削除された直後でも判定できるようにキャッシュは使わない
+func (cmd *Vps) vmExists(vmId string) (bool, error) {
+	servers, err := (&VpsList{Vps: cmd.withRefresh()}).List(false)
+	if err != nil {
+		return false, err
+	}
+
+	for _, vm := range servers {
+		if vm.Id == vmId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/hironobu-s/conoha-vps/cpanel"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseServerStatus(t *testing.T) {
+	if s := parseServerStatus("Running", "1"); s != StatusRunning {
+		t.Errorf("status should be Running. [%s]", s)
+	}
+
+	if s := parseServerStatus("In-formulation", ""); s != StatusInFormulation {
+		t.Errorf("status should be Preparing. [%s]", s)
+	}
+
+	// status_nameが未知の場合はstatus_idで判定する
+	if s := parseServerStatus("稼働中", "1"); s != StatusRunning {
+		t.Errorf("status should be detected by status_id. [%s]", s)
+	}
+
+	// コントロールパネルが返すことを確認していない値はUnknownにする
+	for _, st := range [][]string{{"foo", "bar"}, {"Shutting-down", ""}, {"Booting", "2"}, {"", "10"}} {
+		if s := parseServerStatus(st[0], st[1]); s != StatusUnknown {
+			t.Errorf("%v: status should be Unknown. [%s]", st, s)
+		}
+	}
+}
+
+func TestServerStatusTransition(t *testing.T) {
+	var s ServerStatus = StatusOffline
+
+	if !s.CanTransitionTo(StatusRunning) {
+		t.Errorf("Offline should transition to Running")
+	}
+
+	if s.CanTransitionTo(StatusInFormulation) {
+		t.Errorf("Offline should not transition to Preparing")
+	}
+
+	if !s.CanReach(StatusRunning) {
+		t.Errorf("Offline should reach Running")
+	}
+
+	s = StatusRunning
+	if s.CanReach(StatusInFormulation) {
+		t.Errorf("Running should not reach Preparing")
+	}
+
+	// 状態が分からない場合は到達できるものとみなす
+	s = StatusUnknown
+	if !s.CanReach(StatusOffline) {
+		t.Errorf("Unknown should reach Offline")
+	}
+}
+
+func TestServerStatusJson(t *testing.T) {
+	vm := &Vm{Id: "aaa", ServerStatus: StatusInFormulation}
+
+	b, err := json.Marshal(vm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"serverStatus":"In-formulation"`) {
+		t.Errorf("status should be output by name. [%s]", b)
+	}
+
+	decoded := &Vm{}
+	if err = json.Unmarshal(b, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ServerStatus != StatusInFormulation {
+		t.Errorf("wrong status. [%s]", decoded.ServerStatus)
+	}
+
+	// 古いキャッシュやスナップショットは数値で保存されている
+	if err = json.Unmarshal([]byte(`{"Id":"aaa","ServerStatus":4,"IPv4netmask":"255.255.254.0"}`), decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ServerStatus != StatusOffline || decoded.IPv4netmask != "255.255.254.0" {
+		t.Errorf("old format should be decoded. [%#v]", decoded)
+	}
+}
+
+func TestSleepUntil(t *testing.T) {
+	// deadlineを過ぎている場合は待たない
+	if sleepUntil(time.Hour, time.Now().Add(-time.Second)) {
+		t.Errorf("should be false after the deadline.")
+	}
+
+	// deadlineまでしか待たない
+	start := time.Now()
+	if !sleepUntil(time.Hour, start.Add(10*time.Millisecond)) || time.Since(start) > time.Second {
+		t.Errorf("should sleep until the deadline. [%s]", time.Since(start))
+	}
+}
+
+func TestWaitErrorExitCode(t *testing.T) {
+	if code := ExitCodeOf(&WaitFailedError{VmId: "x", Status: StatusRunning, Target: StatusInFormulation}); code != ExitCodeNG {
+		t.Errorf("got %d", code)
+	}
+	if code := ExitCodeOf(&WaitTimeoutError{VmId: "x"}); code != ExitCodeTimeout {
+		t.Errorf("got %d", code)
+	}
+}
+
+func newTestWait(condition string) *VpsWait {
+	return &VpsWait{
+		Vps:       &Vps{Command: &Command{browser: cpanel.NewBrowser().Fork()}, refresh: true},
+		vmId:      "f648a6646b7e7d91",
+		condition: condition,
+		timeout:   5 * time.Second,
+		interval:  10 * time.Millisecond,
+	}
+}
+
+func TestWaitForExistenceRetry(t *testing.T) {
+	// 一時的な通信エラーでは中断しない
+	defer useFakePanel(2)()
+
+	if err := newTestWait(WaitExists).waitForExistence(true); err != nil {
+		t.Errorf("should retry after the errors. [%v]", err)
+	}
+}
+
+func TestWaitForStatusTimeout(t *testing.T) {
+	// ステータスを一度も取得できなかった場合は、最後のステータスを表示しない
+	defer useFakePanel(1000)()
+
+	cmd := newTestWait(WaitRunning)
+	_, err := cmd.WaitForStatus(cmd.vmId, StatusRunning, &WaitOptions{Timeout: 30 * time.Millisecond, Interval: 10 * time.Millisecond})
+	if _, ok := err.(*WaitTimeoutError); !ok || strings.Contains(err.Error(), "Last status") {
+		t.Errorf("got %v", err)
+	}
+}
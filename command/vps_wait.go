@@ -0,0 +1,177 @@
+package command
+
+// VPSが指定した状態になるまで待つ
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"time"
+)
+
+// 待機する条件
+const (
+	WaitRunning = "running"
+	WaitOffline = "offline"
+	WaitExists  = "exists"
+	WaitGone    = "gone"
+)
+
+type VpsWait struct {
+	vmId      string
+	condition string
+	timeout   time.Duration
+	interval  time.Duration
+	*Vps
+}
+
+func NewVpsWait() *VpsWait {
+	return &VpsWait{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *VpsWait) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.StringVarP(&cmd.condition, "status", "s", WaitRunning, "")
+	fs.DurationVarP(&cmd.timeout, "timeout", "t", DefaultWaitTimeout, "")
+	fs.DurationVarP(&cmd.interval, "interval", "i", DefaultWaitInterval, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	switch cmd.condition {
+	case WaitRunning, WaitOffline, WaitExists, WaitGone:
+	default:
+		fs.Usage()
+		return errors.New(lib.T(`Undefined status "%s".`, cmd.condition))
+	}
+
+	if cmd.timeout <= 0 || cmd.interval <= 0 {
+		return errors.New(lib.T("Timeout and interval should be greater than zero."))
+	}
+
+	// スクリプトから使うことを想定しているので、VPS-IDは必須とする
+	if len(fs.Args()) < 2 {
+		fs.Usage()
+		return errors.New(lib.T("Not enough arguments."))
+	}
+	cmd.vmId = fs.Args()[1]
+
+	return nil
+}
+
+func (cmd *VpsWait) Usage() {
+	printUsage("wait")
+}
+
+func (cmd *VpsWait) Run() error {
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+	lib.AddLogField("vps_id", cmd.vmId)
+
+	// 常に最新の情報を取得する
+	cmd.refresh = true
+
+	switch cmd.condition {
+	case WaitExists:
+		err = cmd.waitForExistence(true)
+	case WaitGone:
+		err = cmd.waitForExistence(false)
+	case WaitRunning:
+		err = cmd.waitForStatus(StatusRunning)
+	case WaitOffline:
+		err = cmd.waitForStatus(StatusOffline)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, lib.T("VPS(id=%s) is %s.", cmd.vmId, cmd.condition))
+	return nil
+}
+
+// ServerStatusが指定の値になるまで待つ
+func (cmd *VpsWait) waitForStatus(target ServerStatus) error {
+	exists, err := cmd.exists()
+	if err != nil {
+		return err
+	} else if !exists {
+		return &VpsNotFoundError{VmId: cmd.vmId}
+	}
+
+	opts := &WaitOptions{
+		Timeout:  cmd.timeout,
+		Interval: cmd.interval,
+		Progress: cmd.progress,
+	}
+
+	_, err = cmd.WaitForStatus(cmd.vmId, target, opts)
+	return err
+}
+
+// VPSが一覧に現れる(exists=true)、もしくは一覧から消える(exists=false)まで待つ
+func (cmd *VpsWait) waitForExistence(exists bool) error {
+	start := time.Now()
+	deadline := start.Add(cmd.timeout)
+	status := ServerStatus(StatusNoinformation)
+
+	for {
+		found, err := cmd.exists()
+		if err != nil {
+			// 一時的なエラーの可能性があるので、タイムアウトまで再試行する
+			lib.GetLogInstance().Debugf("could not get the VPS list: %s", err)
+		} else {
+			status = StatusNoinformation
+			if found {
+				// VPSが存在する場合は進捗表示のためにステータスも取得しておく
+				status, _ = cmd.GetVMStatus(cmd.vmId)
+			}
+			cmd.progress(cmd.vmId, status, time.Since(start))
+
+			if found == exists {
+				return nil
+			}
+		}
+
+		if !sleepUntil(cmd.interval, deadline) {
+			return &WaitTimeoutError{
+				VmId:    cmd.vmId,
+				Status:  status,
+				Timeout: cmd.timeout,
+			}
+		}
+	}
+}
+
+// VPSが一覧に存在するかを返す
+func (cmd *VpsWait) exists() (bool, error) {
+	return cmd.vmExists(cmd.vmId)
+}
+
+// 進捗を標準エラー出力に表示する
+func (cmd *VpsWait) progress(vmId string, status ServerStatus, elapsed time.Duration) {
+	var state string
+	if status == StatusNoinformation {
+		state = lib.T("not found")
+	} else {
+		state = status.String()
+	}
+
+	fmt.Fprintln(os.Stderr, lib.T("Waiting for VPS(id=%s) to be %s... %s (%s elapsed)", vmId, cmd.condition, state, elapsed/time.Second*time.Second))
+}
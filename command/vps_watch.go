@@ -0,0 +1,246 @@
+package command
+
+// VPSの一覧とステータスを定期的に取得して、変化があればJSONで出力する
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hironobu-s/conoha-vps/lib"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// 変化の種類
+const (
+	EventAppeared    = "appeared"
+	EventDisappeared = "disappeared"
+	EventChanged     = "changed"
+)
+
+// VPSの変化を表す構造体
+// 一行のJSONとして出力される
+type VpsEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Id    string    `json:"id"`
+	Label string    `json:"label"`
+	Field string    `json:"field,omitempty"`
+	Old   string    `json:"old,omitempty"`
+	New   string    `json:"new,omitempty"`
+}
+
+// 変化を検出するフィールド
+var watchFields = []string{"ServerStatus", "Label", "ServiceStatus", "DeleteDate"}
+
+type VpsWatch struct {
+	interval    time.Duration
+	maxBackoff  time.Duration
+	emitInitial bool
+	*Vps
+}
+
+func NewVpsWatch() *VpsWatch {
+	return &VpsWatch{
+		Vps: NewVps(),
+	}
+}
+
+func (cmd *VpsWatch) parseFlag() error {
+	var help bool
+
+	fs := newFlagSet(cmd.Usage)
+
+	fs.BoolVarP(&help, "help", "h", false, "")
+	fs.DurationVarP(&cmd.interval, "interval", "i", time.Minute, "")
+	fs.DurationVarP(&cmd.maxBackoff, "max-backoff", "b", 10*time.Minute, "")
+	fs.BoolVarP(&cmd.emitInitial, "emit-initial", "a", false, "")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	if help {
+		fs.Usage()
+		return &ShowUsageError{}
+	}
+
+	if cmd.interval <= 0 {
+		return errors.New(lib.T("Interval should be greater than zero."))
+	}
+
+	if cmd.maxBackoff < cmd.interval {
+		cmd.maxBackoff = cmd.interval
+	}
+
+	return nil
+}
+
+func (cmd *VpsWatch) Usage() {
+	printUsage("watch")
+}
+
+func (cmd *VpsWatch) Run() error {
+	log := lib.GetLogInstance()
+
+	var err error
+	if err = cmd.parseFlag(); err != nil {
+		return usageError(err)
+	}
+
+	// 常に最新の情報を取得する
+	cmd.refresh = true
+
+	// 中断された場合も、Shutdown()でセッションIDを保存できるようにする
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	enc := json.NewEncoder(os.Stdout)
+
+	// 一覧の取得とログインは同じセッションを使い続ける
+	// NewCommand()は設定ファイルのセッションIDをブラウザにセットし直すので、ループの中では作らない
+	vpsList := &VpsList{Vps: cmd.Vps}
+	login := &Login{Command: cmd.Command}
+
+	var prev []*Vm
+	initialized := false
+	wait := cmd.interval
+
+	for {
+		servers, err := cmd.crawl(vpsList, login)
+		if err != nil {
+			// エラーが続く場合は間隔を延ばす
+			wait *= 2
+			if wait > cmd.maxBackoff {
+				wait = cmd.maxBackoff
+			}
+			log.Warn(lib.T("%s (retry after %s)", err, wait))
+
+		} else {
+			wait = cmd.interval
+
+			if initialized || cmd.emitInitial {
+				for _, ev := range diffServers(prev, servers, time.Now()) {
+					if err = enc.Encode(ev); err != nil {
+						return err
+					}
+				}
+			}
+			prev = servers
+			initialized = true
+		}
+
+		select {
+		case <-sig:
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// VPSの一覧をステータス付きで取得する
+// セッションが切れている場合は再ログインする
+func (cmd *VpsWatch) crawl(vpsList *VpsList, l *Login) ([]*Vm, error) {
+	servers, err := vpsList.List(true)
+	if err != nil {
+		return nil, err
+	}
+
+	// セッションが切れているとVPSの一覧が空になるので、ログイン状態を確認する
+	if len(servers) == 0 {
+		loggedIn, err := l.LoggedIn()
+		if err != nil {
+			return nil, err
+		}
+
+		if !loggedIn {
+			lib.GetLogInstance().Debugf("Session is timed out. try relogin...")
+
+			if loggedIn, err = l.Relogin(); err != nil {
+				return nil, err
+			} else if !loggedIn {
+				return nil, &AuthError{}
+			}
+
+			return vpsList.List(true)
+		}
+	}
+	return servers, nil
+}
+
+// VPSを識別するキー
+// 作成中のVPSはIDを持たない場合があるので、その場合はTrIdを使う
+func vmKey(vm *Vm) string {
+	if vm.Id != "" {
+		return vm.Id
+	}
+	return vm.TrId
+}
+
+// 二つのVPS一覧を比較して、変化をVpsEventのスライスで返す
+func diffServers(prev []*Vm, cur []*Vm, now time.Time) []*VpsEvent {
+	events := []*VpsEvent{}
+
+	olds := map[string]*Vm{}
+	for _, vm := range prev {
+		olds[vmKey(vm)] = vm
+	}
+
+	news := map[string]bool{}
+	for _, vm := range cur {
+		key := vmKey(vm)
+		news[key] = true
+
+		old, exists := olds[key]
+		if !exists {
+			events = append(events, &VpsEvent{
+				Time:  now,
+				Event: EventAppeared,
+				Id:    vm.Id,
+				Label: vm.Label,
+			})
+			continue
+		}
+
+		// ステータスが取得できなかった場合は、前回のステータスを引き継ぐ
+		if vm.ServerStatus == StatusUnknown || vm.ServerStatus == StatusNoinformation {
+			vm.ServerStatus = old.ServerStatus
+		}
+
+		for _, c := range compareVm(old, vm, watchFields) {
+			events = append(events, &VpsEvent{
+				Time:  now,
+				Event: EventChanged,
+				Id:    vm.Id,
+				Label: vm.Label,
+				Field: c.Field,
+				Old:   c.Old,
+				New:   c.New,
+			})
+		}
+	}
+
+	for _, vm := range prev {
+		if !news[vmKey(vm)] {
+			events = append(events, &VpsEvent{
+				Time:  now,
+				Event: EventDisappeared,
+				Id:    vm.Id,
+				Label: vm.Label,
+			})
+		}
+	}
+
+	return events
+}
+
+// 日付を文字列にする。ゼロ値の場合は空文字列を返す
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffServers(t *testing.T) {
+	now := time.Now()
+
+	prev := []*Vm{
+		{Id: "aaa", Label: "web", ServerStatus: StatusRunning, ServiceStatus: "In operation"},
+		{Id: "bbb", Label: "db", ServerStatus: StatusRunning, ServiceStatus: "In operation"},
+	}
+
+	cur := []*Vm{
+		{Id: "aaa", Label: "web1", ServerStatus: StatusOffline, ServiceStatus: "In operation"},
+		{Id: "ccc", Label: "new", ServerStatus: StatusInFormulation},
+	}
+
+	events := diffServers(prev, cur, now)
+	if len(events) != 4 {
+		t.Fatalf("the number of events should be 4. [%d]", len(events))
+	}
+
+	expected := []struct {
+		event string
+		id    string
+		field string
+	}{
+		{EventChanged, "aaa", "ServerStatus"},
+		{EventChanged, "aaa", "Label"},
+		{EventAppeared, "ccc", ""},
+		{EventDisappeared, "bbb", ""},
+	}
+
+	for i, e := range expected {
+		if events[i].Event != e.event || events[i].Id != e.id || events[i].Field != e.field {
+			t.Errorf("wrong event. [%d: %#v]", i, events[i])
+		}
+	}
+}
+
+func TestDiffServersUnknownStatus(t *testing.T) {
+	prev := []*Vm{
+		{Id: "aaa", ServerStatus: StatusRunning},
+	}
+
+	// ステータスが取得できなかった場合は変化とみなさない
+	cur := []*Vm{
+		{Id: "aaa", ServerStatus: StatusUnknown},
+	}
+
+	if events := diffServers(prev, cur, time.Now()); len(events) != 0 {
+		t.Errorf("unknown status should not be reported. [%d]", len(events))
+	}
+}
@@ -3,10 +3,13 @@ package cpanel
 import (
 	"errors"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/Sirupsen/logrus"
+	"github.com/hironobu-s/conoha-vps/lib"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // コントロールパネル上のアクション
@@ -55,13 +58,23 @@ func (act *Action) Run(bi *BrowserInfo) (err error) {
 
 	// HTTPリクエスト実行
 	cli := &http.Client{Jar: bi.cookiejar}
+	start := time.Now()
 	resp, err := cli.Do(req)
 
+	fields := logrus.Fields{
+		"url":      req.URL.String(),
+		"method":   req.Method,
+		"duration": time.Since(start).String(),
+	}
 	if err != nil {
+		lib.GetLogInstance().WithFields(fields).Debugf("request failed: %s", err)
 		return err
 	}
 	defer resp.Body.Close()
 
+	fields["status"] = resp.StatusCode
+	lib.GetLogInstance().WithFields(fields).Debug("request")
+
 	// dump, _ := httputil.DumpRequest(req, true)
 	// println(string(dump))
 
@@ -177,6 +190,9 @@ type Browser struct {
 
 	// 実行するリクエストのスライス
 	actions []*Action
+
+	// trueの場合は取り消せない操作のリクエストを送信しない(--dry-run)
+	DryRun bool
 }
 
 var browserInstance *Browser
@@ -195,6 +211,21 @@ func NewBrowser() *Browser {
 	return browserInstance
 }
 
+// セッション(CookieJar)を共有した別のBrowserを作成する
+// Browserは並行して使えないので、goroutineごとにForkしたものを使うこと
+func (b *Browser) Fork() *Browser {
+	info := &BrowserInfo{
+		cookiejar: b.BrowserInfo.cookiejar,
+		headers:   b.BrowserInfo.headers,
+		Values:    url.Values{},
+	}
+
+	return &Browser{
+		BrowserInfo: info,
+		DryRun:      b.DryRun,
+	}
+}
+
 // アクションを追加する
 func (b *Browser) AddAction(act *Action) {
 	b.actions = append(b.actions, act)
@@ -205,8 +236,15 @@ func (b *Browser) ClearAction() {
 	b.actions = []*Action{}
 }
 
+// アクションを順番に実行する
+// ドライランの場合は、取り消せない操作のアクションの手前で止めてDryRunErrorを返す
 func (b *Browser) Run() error {
 	for _, act := range b.actions {
+		if _, ok := act.Request.(IrreversibleRequester); ok && b.DryRun {
+			err := act.dryRun(b.BrowserInfo)
+			b.ClearAction()
+			return err
+		}
 
 		err := act.Run(b.BrowserInfo)
 		if err != nil {
@@ -0,0 +1,66 @@
+package cpanel
+
+// ドライラン
+// 取り消せない操作(VPSの追加、削除、電源操作、ラベルの変更)のリクエストは送信せずに、送信する内容を返す
+
+import (
+	"io/ioutil"
+	"net/url"
+)
+
+// 取り消せない操作のリクエストはこのインターフェイスを実装する
+type IrreversibleRequester interface {
+	ActionRequester
+
+	// 実装する必要はない。このインターフェイスを実装していることを示すためのメソッド
+	Irreversible()
+}
+
+// ドライランで送信しなかったリクエスト
+type DryRunError struct {
+	Method string
+	Url    string
+
+	// 送信するパラメータ(URLのクエリとPOSTのフォーム)
+	Values url.Values
+}
+
+func (e *DryRunError) Error() string {
+	return "dry run: " + e.Method + " " + e.Url
+}
+
+// リクエストを作成して、送信せずにDryRunErrorを返す
+func (act *Action) dryRun(bi *BrowserInfo) error {
+	req, err := act.Request.NewRequest(bi.Values)
+	if err != nil {
+		return err
+	}
+
+	e := &DryRunError{
+		Method: req.Method,
+		Values: req.URL.Query(),
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	e.Url = u.String()
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		req.Body.Close()
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return err
+		}
+		for key, values := range form {
+			for _, value := range values {
+				e.Values.Add(key, value)
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,21 @@
+package cpanel
+
+import (
+	"fmt"
+)
+
+// コントロールパネルのHTMLが想定と異なり、パースできない場合のエラー
+// コントロールパネルの仕様が変わった可能性がある
+type MarkupError struct {
+	s string
+}
+
+func NewMarkupError(format string, a ...interface{}) *MarkupError {
+	return &MarkupError{
+		s: fmt.Sprintf(format, a...),
+	}
+}
+
+func (e *MarkupError) Error() string {
+	return e.s
+}
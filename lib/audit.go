@@ -0,0 +1,162 @@
+package lib
+
+// VPSを変更する操作(add, remove, power, label)の監査ログ
+// 設定ディレクトリの audit.log に1行1件のJSONで追記する
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	AUDITFILE = "audit.log"
+)
+
+// 監査ログに記録する操作
+const (
+	AuditAdd    = "add"
+	AuditRemove = "remove"
+	AuditPower  = "power"
+	AuditLabel  = "label"
+)
+
+var AuditOperations = []string{AuditAdd, AuditRemove, AuditPower, AuditLabel}
+
+// 確認の方法
+const (
+	AuditConfirmPrompt = "prompt" // 確認のプロンプトを表示した
+	AuditConfirmForce  = "force"  // -f で確認を省略した
+)
+
+// パラメータの伏せ字
+const auditRedacted = "********"
+
+// 監査ログの1件
+// フィールド名はログを集計するスクリプトから参照されるので変更しないこと
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Account   string    `json:"account"`
+	Operation string    `json:"operation"`
+	VpsId     string    `json:"vpsId,omitempty"`
+	Label     string    `json:"label,omitempty"`
+
+	// コマンドに指定したパラメータ。パスワードは伏せ字になる
+	Params map[string]string `json:"params,omitempty"`
+
+	// 確認の方法(AuditConfirm*定数)。確認しない操作の場合は空
+	Confirmation string `json:"confirmation,omitempty"`
+
+	// 結果(成功した場合は"ok"、それ以外は終了コードの種類)とエラーメッセージ
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// 監査ログを作成する。時刻、OSのユーザー、アカウントはここでセットする
+func NewAuditRecord(config *Config, operation string) *AuditRecord {
+	return &AuditRecord{
+		Time:      time.Now(),
+		User:      osUserName(),
+		Account:   config.Account,
+		Operation: operation,
+	}
+}
+
+// OSのユーザー名
+func osUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// パスワードなどを伏せ字にしたパラメータを返す
+func RedactParams(params map[string]string) map[string]string {
+	redacted := map[string]string{}
+	for key, value := range params {
+		lower := strings.ToLower(key)
+		if value != "" && (strings.Contains(lower, "password") || strings.Contains(lower, "secret")) {
+			value = auditRedacted
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// 監査ログのパスを返す
+func AuditLogPath(config *Config) (string, error) {
+	dir, err := config.ConfigDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, AUDITFILE), nil
+}
+
+// 監査ログに追記する
+func WriteAuditRecord(config *Config, record *AuditRecord) error {
+	path, err := AuditLogPath(config)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	record.Params = RedactParams(record.Params)
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(b, '\n'))
+	return err
+}
+
+// 監査ログを全て読み込む
+// 監査ログがない場合は空のスライスを返す。壊れた行は読み飛ばす
+func ReadAuditRecords(config *Config) ([]*AuditRecord, error) {
+	records := []*AuditRecord{}
+
+	path, err := AuditLogPath(config)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	log := GetLogInstance()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		record := &AuditRecord{}
+		if err := json.Unmarshal(line, record); err != nil {
+			log.Debugf("skip the broken line %d of the audit log: %s", n, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,177 @@
+package lib
+
+// VPSの一覧や詳細情報をファイルにキャッシュする
+// キャッシュは設定ディレクトリの cache/{アカウント}/ 以下に保存される
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DefaultCacheTTL = 60 * time.Second
+)
+
+// キャッシュの動作モード
+type CacheMode int
+
+const (
+	CacheEnabled  CacheMode = iota // キャッシュを読み書きする
+	CacheRefresh                   // キャッシュを読まずに、取得した結果で更新する
+	CacheDisabled                  // キャッシュを読み書きしない
+)
+
+type Cache struct {
+	// キャッシュの有効期間
+	TTL time.Duration
+
+	// 動作モード
+	Mode CacheMode
+
+	// キャッシュを保存するディレクトリ
+	dir string
+}
+
+// キャッシュファイルの中身
+type cacheEntry struct {
+	StoredAt time.Time
+	Data     json.RawMessage
+}
+
+var cacheInstance *Cache
+
+func GetCacheInstance() *Cache {
+	if cacheInstance == nil {
+		cacheInstance = &Cache{
+			TTL:  DefaultCacheTTL,
+			Mode: CacheEnabled,
+		}
+	}
+	return cacheInstance
+}
+
+// 設定ファイルの内容からキャッシュの保存先と有効期間を決定する
+func (c *Cache) Configure(config *Config) {
+	if config.CacheTTL > 0 {
+		c.TTL = time.Duration(config.CacheTTL) * time.Second
+	}
+
+	c.dir = ""
+	if config.Account == "" {
+		// ログインしていない場合はキャッシュしない
+		return
+	}
+
+	dir, err := config.ConfigDirPath()
+	if err != nil {
+		return
+	}
+	c.dir = filepath.Join(dir, "cache", filepath.Base(config.Account))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// キャッシュを読み込んでvにセットする
+// 有効なキャッシュが存在した場合にtrueを返す
+func (c *Cache) Get(key string, v interface{}) bool {
+	if c.Mode != CacheEnabled {
+		return false
+	}
+	return c.read(key, v, c.TTL)
+}
+
+// 有効期間を過ぎていてもキャッシュを読み込んでvにセットする
+// シェルの補完のように、古くても速く返したい場合に使う
+func (c *Cache) GetStale(key string, v interface{}) bool {
+	if c.Mode == CacheDisabled {
+		return false
+	}
+	return c.read(key, v, 0)
+}
+
+// キャッシュファイルを読み込む。ttlが0の場合は有効期間をチェックしない
+func (c *Cache) read(key string, v interface{}, ttl time.Duration) bool {
+	if c.dir == "" {
+		return false
+	}
+
+	file, err := os.Open(c.path(key))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	entry := &cacheEntry{}
+	if err = json.NewDecoder(file).Decode(entry); err != nil {
+		return false
+	}
+
+	if ttl > 0 && time.Since(entry.StoredAt) > ttl {
+		return false
+	}
+
+	if err = json.Unmarshal(entry.Data, v); err != nil {
+		return false
+	}
+
+	GetLogInstance().Debugf("cache hit: %s", key)
+	return true
+}
+
+// vをキャッシュに保存する
+func (c *Cache) Set(key string, v interface{}) error {
+	if c.dir == "" || c.Mode == CacheDisabled {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	// 並行して読んでいる処理が書きかけのファイルを読まないように、一時ファイルに書いてから置き換える
+	file, err := ioutil.TempFile(c.dir, key+".tmp")
+	if err != nil {
+		return err
+	}
+
+	entry := &cacheEntry{
+		StoredAt: time.Now(),
+		Data:     data,
+	}
+	if err = json.NewEncoder(file).Encode(entry); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	if err = os.Rename(file.Name(), c.path(key)); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// キャッシュをすべて削除する
+// VPSの状態を変更するコマンドを実行したときに呼ぶ
+func (c *Cache) Clear() {
+	if c.dir == "" {
+		return
+	}
+
+	GetLogInstance().Debugf("cache cleared: %s", c.dir)
+	os.RemoveAll(c.dir)
+}
@@ -5,18 +5,161 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 var Version string
 
 const (
 	CONFIGFILE = ".conoha-vps"
+	CONFIGDIR  = ".conoha-vps.d"
 )
 
+// デフォルトのプロファイル名
+// デフォルトのプロファイルは、プロファイルに対応する前と同じく設定ファイルのトップレベルに保存する
+const DefaultProfile = "default"
+
+// プロファイルごとのアカウントとセッション
+type Profile struct {
+	Account  string
+	Password string
+	Sid      string
+}
+
 type Config struct {
+	// 使用中のプロファイルのアカウントとセッション
 	Account  string
 	Password string
 	Sid      string
+
+	// キャッシュの有効期間(秒)。0の場合はデフォルト値を使う
+	CacheTTL int `json:",omitempty"`
+
+	// ログの設定。--log-level --log-format --log-file が指定された場合はそちらを優先する
+	LogLevel  string `json:",omitempty"`
+	LogFormat string `json:",omitempty"`
+	LogFile   string `json:",omitempty"`
+
+	// conoha profile use で選択したプロファイル。空の場合はデフォルトのプロファイル
+	CurrentProfile string `json:",omitempty"`
+
+	// デフォルト以外のプロファイル
+	Profiles map[string]*Profile `json:",omitempty"`
+
+	// 使用中のプロファイル名。空の場合はデフォルトのプロファイル
+	profile string
+
+	// デフォルト以外のプロファイルを使用中の場合の、デフォルトのプロファイル
+	defaultProfile Profile
+}
+
+// 使用中のプロファイル名を返す
+func (c *Config) Profile() string {
+	if c.profile == "" {
+		return DefaultProfile
+	}
+	return c.profile
+}
+
+// プロファイルが存在するか
+func (c *Config) HasProfile(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	_, ok := c.Profiles[name]
+	return ok
+}
+
+// 全てのプロファイル名を返す。デフォルトのプロファイルが先頭で、それ以外は名前の順
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// プロファイルのアカウントとセッションを返す。存在しない場合はnilを返す
+func (c *Config) GetProfile(name string) *Profile {
+	c.saveProfile()
+	if name == DefaultProfile {
+		if c.profile == "" {
+			return &Profile{Account: c.Account, Password: c.Password, Sid: c.Sid}
+		}
+		p := c.defaultProfile
+		return &p
+	}
+	return c.Profiles[name]
+}
+
+// プロファイルを追加する(既に存在する場合は上書きする)
+func (c *Config) SetProfile(name string, p *Profile) {
+	c.saveProfile()
+	if name == DefaultProfile {
+		if c.profile == "" {
+			c.Account, c.Password, c.Sid = p.Account, p.Password, p.Sid
+		} else {
+			c.defaultProfile = *p
+		}
+		return
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	c.Profiles[name] = p
+	if name == c.profile {
+		c.Account, c.Password, c.Sid = p.Account, p.Password, p.Sid
+	}
+}
+
+// プロファイルを削除する。デフォルトのプロファイルは削除できない
+func (c *Config) RemoveProfile(name string) {
+	delete(c.Profiles, name)
+	if len(c.Profiles) == 0 {
+		c.Profiles = nil
+	}
+	if c.CurrentProfile == name {
+		c.CurrentProfile = ""
+	}
+}
+
+// 使用するプロファイルを切り替える
+// nameが空の場合は、conoha profile use で選択したプロファイルを使う
+// 存在しないプロファイルの場合はfalseを返す
+func (c *Config) UseProfile(name string) bool {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	if !c.HasProfile(name) {
+		return false
+	}
+
+	// 今のプロファイルを保存して、デフォルトのプロファイルに戻す
+	c.saveProfile()
+	if c.profile != "" {
+		c.Account, c.Password, c.Sid = c.defaultProfile.Account, c.defaultProfile.Password, c.defaultProfile.Sid
+		c.profile = ""
+	}
+
+	if name != DefaultProfile {
+		c.defaultProfile = Profile{Account: c.Account, Password: c.Password, Sid: c.Sid}
+		p := c.Profiles[name]
+		c.Account, c.Password, c.Sid = p.Account, p.Password, p.Sid
+		c.profile = name
+	}
+	return true
+}
+
+// 使用中のプロファイルのアカウントとセッションをProfilesに書き戻す
+func (c *Config) saveProfile() {
+	if p, ok := c.Profiles[c.profile]; ok && c.profile != "" {
+		p.Account, p.Password, p.Sid = c.Account, c.Password, c.Sid
+	}
 }
 
 func (c *Config) ConfigFilePath() (string, error) {
@@ -28,6 +171,21 @@ func (c *Config) ConfigFilePath() (string, error) {
 	return homedir + string(filepath.Separator) + CONFIGFILE, nil
 }
 
+// キャッシュなどを保存するディレクトリのパスを返す
+// ディレクトリが存在しない場合は作成する
+func (c *Config) ConfigDirPath() (string, error) {
+	homedir, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	path := homedir + string(filepath.Separator) + CONFIGDIR
+	if err = os.MkdirAll(path, 0700); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 func (c *Config) Remove() {
 	var err error
 
@@ -80,8 +238,15 @@ func (c *Config) Write() error {
 		return err
 	}
 
+	// デフォルト以外のプロファイルを使用中の場合も、トップレベルにはデフォルトのプロファイルを保存する
+	c.saveProfile()
+	data := *c
+	if c.profile != "" {
+		data.Account, data.Password, data.Sid = c.defaultProfile.Account, c.defaultProfile.Password, c.defaultProfile.Sid
+	}
+
 	enc := json.NewEncoder(file)
-	err = enc.Encode(c)
+	err = enc.Encode(&data)
 	if err != nil {
 		return err
 	}
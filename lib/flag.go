@@ -1,3 +1,72 @@
 package lib
 
-import ()
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 全てのコマンドで使える長い名前のオプション(--name value と --name=value)をargsから取り除く
+// 各コマンドはFlagSetで未定義のオプションをエラーにするので、コマンドに渡す前に呼ぶこと
+// オプションが見つからない場合はdefaultValueを返す
+func ExtractFlag(args []string, name string, defaultValue string) (value string, rest []string) {
+	value = defaultValue
+	rest = []string{}
+
+	flag := "--" + name
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// "--" 以降はコマンドの引数
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		if arg == flag && i+1 < len(args) {
+			value = args[i+1]
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(arg, flag+"=") {
+			value = strings.TrimPrefix(arg, flag+"=")
+			continue
+		}
+
+		rest = append(rest, arg)
+	}
+	return value, rest
+}
+
+// 全てのコマンドで使える真偽値のオプション(--name と --name=true/false)をargsから取り除く
+// オプションが見つからない場合はfalseを返す
+func ExtractBoolFlag(args []string, name string) (value bool, rest []string, err error) {
+	rest = []string{}
+
+	flag := "--" + name
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		if arg == flag {
+			value = true
+			continue
+		}
+
+		if strings.HasPrefix(arg, flag+"=") {
+			value, err = strconv.ParseBool(strings.TrimPrefix(arg, flag+"="))
+			if err != nil {
+				return false, nil, errors.New(T(`Invalid value "%s" for %s.`, arg, flag))
+			}
+			continue
+		}
+
+		rest = append(rest, arg)
+	}
+	return value, rest, nil
+}
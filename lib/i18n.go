@@ -0,0 +1,92 @@
+package lib
+
+// メッセージの翻訳
+// メッセージは英語の文字列をキーにして、言語ごとのカタログから翻訳を探す
+// 翻訳が見つからない場合は英語のまま表示する
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 対応している言語
+const (
+	LangEn = "en"
+	LangJa = "ja"
+)
+
+var Langs = []string{LangEn, LangJa}
+
+// 現在の言語
+var currentLang = LangEn
+
+// 言語ごとのメッセージカタログ
+var catalogs = map[string]map[string]string{}
+
+// メッセージカタログを登録する
+// 同じキーが登録済みの場合は上書きする
+func RegisterMessages(lang string, messages map[string]string) {
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for key, msg := range messages {
+		catalogs[lang][key] = msg
+	}
+}
+
+// 表示する言語を設定する
+func SetLang(lang string) error {
+	for _, l := range Langs {
+		if l == lang {
+			currentLang = lang
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf(`Undefined language "%s". It should be "en" or "ja".`, lang))
+}
+
+// 現在の言語を返す
+func Lang() string {
+	return currentLang
+}
+
+// 環境変数(LC_ALL, LC_MESSAGES, LANG)から言語を決定する
+// "ja_JP.UTF-8" などの場合は日本語、それ以外は英語になる
+func DetectLang() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if strings.HasPrefix(strings.ToLower(value), "ja") {
+			return LangJa
+		}
+		return LangEn
+	}
+	return LangEn
+}
+
+// メッセージを翻訳する
+// argsがある場合はfmt.Sprintfでフォーマットする
+func T(msg string, args ...interface{}) string {
+	if translated, ok := catalogs[currentLang][msg]; ok {
+		msg = translated
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// IDを指定してテキストを翻訳する
+// Usageのような長いテキストはIDで登録しておき、翻訳が見つからない場合はdefaultTextを返す
+func Text(id string, defaultText string) string {
+	if translated, ok := catalogs[currentLang][id]; ok {
+		return translated
+	}
+	return defaultText
+}
@@ -2,14 +2,24 @@ package lib
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/Sirupsen/logrus"
+	"os"
 	"sort"
+	"sync"
 )
 
 func init() {
 }
 
+// ログのフォーマット
+const (
+	LogFormatText   = "text"
+	LogFormatSimple = "simple"
+	LogFormatJson   = "json"
+)
+
 var instance *logrus.Logger
 
 func GetLogInstance() *logrus.Logger {
@@ -18,12 +28,119 @@ func GetLogInstance() *logrus.Logger {
 		instance = logrus.New()
 
 		instance.Level = logrus.InfoLevel
-		//instance.Formatter = &SimpleFormatter{}
 		instance.Formatter = &logrus.TextFormatter{}
+		instance.Hooks.Add(logFields)
 	}
 	return instance
 }
 
+// ログの設定
+// 空文字列の項目はデフォルトのままにする
+type LogOptions struct {
+	// ログレベル(debug, info, warning, error)
+	Level string
+
+	// フォーマット(text, simple, json)
+	Format string
+
+	// ログを追記するファイル
+	// 指定した場合も標準エラー出力には出力する
+	File string
+}
+
+// ログのレベル、フォーマット、出力先を設定する
+func ConfigureLog(opts *LogOptions) error {
+	log := GetLogInstance()
+
+	if opts.Level != "" {
+		level, err := logrus.ParseLevel(opts.Level)
+		if err != nil {
+			return errors.New(T(`Undefined log level "%s".`, opts.Level))
+		}
+		log.Level = level
+	}
+
+	switch opts.Format {
+	case "", LogFormatText:
+		log.Formatter = &logrus.TextFormatter{}
+	case LogFormatSimple:
+		log.Formatter = &SimpleFormatter{}
+	case LogFormatJson:
+		log.Formatter = &logrus.JSONFormatter{}
+	default:
+		return errors.New(T(`Undefined log format "%s". It should be "text", "simple" or "json".`, opts.Format))
+	}
+
+	if opts.File != "" {
+		file, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+
+		// ファイルには色のエスケープシーケンスを書き込まない
+		formatter := log.Formatter
+		if _, ok := formatter.(*logrus.TextFormatter); ok {
+			formatter = &logrus.TextFormatter{DisableColors: true}
+		}
+		log.Hooks.Add(&logFileHook{file: file, formatter: formatter})
+	}
+	return nil
+}
+
+// 全てのログに付加するフィールドを追加する
+// (command, vps_id など)
+func AddLogField(key string, value interface{}) {
+	logFields.mu.Lock()
+	defer logFields.mu.Unlock()
+	logFields.fields[key] = value
+}
+
+// ログにフィールドを付加するフック
+// 複数のgoroutineからログを出力するのでロックする
+type logFieldsHook struct {
+	mu     sync.RWMutex
+	fields logrus.Fields
+}
+
+var logFields = &logFieldsHook{fields: logrus.Fields{}}
+
+func (h *logFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logFieldsHook) Fire(entry *logrus.Entry) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for key, value := range h.fields {
+		// WithFieldsで指定されたものを優先する
+		if _, exists := entry.Data[key]; !exists {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
+
+// ログをファイルにも書き込むフック
+type logFileHook struct {
+	file      *os.File
+	formatter logrus.Formatter
+}
+
+func (h *logFileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logFileHook) Fire(entry *logrus.Entry) error {
+	b, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.file.Write(b)
+	return err
+}
+
+// メッセージの後にフィールドを key=value の形式で出力する
 type SimpleFormatter struct {
 }
 
@@ -48,6 +165,14 @@ func (f *SimpleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 
 	fmt.Fprintf(b, "%s", entry.Message)
 
+	if len(keys) > 0 {
+		b.WriteByte(' ')
+		for _, key := range keys {
+			f.appendKeyValue(b, key, entry.Data[key])
+		}
+		b.Truncate(b.Len() - 1)
+	}
+
 	b.WriteByte('\n')
 	return b.Bytes(), nil
 
@@ -0,0 +1,131 @@
+package lib
+
+// コマンドの結果をJSONやCSVなどの形式で出力する
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 出力フォーマット
+const (
+	OutputTable = "table"
+	OutputJson  = "json"
+	OutputYaml  = "yaml"
+	OutputCsv   = "csv"
+	OutputTsv   = "tsv"
+)
+
+var OutputFormats = []string{OutputTable, OutputJson, OutputYaml, OutputCsv, OutputTsv}
+
+// CSVやTSVで出力する表
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// 出力フォーマットが正しいかをチェックする
+func ValidateOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return errors.New(T(`Undefined output format "%s".`, format))
+}
+
+// dataを指定したフォーマットで出力する
+// JSONとYAMLはdataを、CSVとTSVはtableを出力する
+// tableフォーマットはコマンドごとに出力が異なるので、ここでは扱わない
+func Render(w io.Writer, format string, data interface{}, table *Table) error {
+	switch format {
+	case OutputJson:
+		b, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+
+	case OutputYaml:
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+
+	case OutputCsv, OutputTsv:
+		cw := csv.NewWriter(w)
+		if format == OutputTsv {
+			cw.Comma = '\t'
+		}
+
+		if err := cw.Write(table.Header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(table.Rows); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return errors.New(T(`Output format "%s" is not supported.`, format))
+	}
+}
+
+// --format で指定されたテンプレートで使える関数
+var templateFuncs = template.FuncMap{
+	// {{join .IPv6 ", "}}
+	"join": func(a []string, sep string) string {
+		return strings.Join(a, sep)
+	},
+
+	// {{upper .Label}}
+	"upper": strings.ToUpper,
+
+	// {{lower .Label}}
+	"lower": strings.ToLower,
+
+	// {{date "2006/01/02" .CreatedAt}} 日付がない場合は空文字列になる
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+
+	// {{json .}}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// --format で指定されたGoのテンプレートをパースする
+// シェルから入力しやすいように、\t と \n はタブと改行に置き換える
+func ParseTemplate(format string) (*template.Template, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	return template.New("format").Funcs(templateFuncs).Parse(format)
+}
+
+// itemsそれぞれをテンプレートで出力する。各行の最後には改行が付く。
+func RenderTemplate(w io.Writer, tmpl *template.Template, items ...interface{}) error {
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
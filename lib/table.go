@@ -0,0 +1,173 @@
+package lib
+
+// 表を端末に出力する
+// 全角文字を含む場合でも表示幅で桁を揃え、端末の幅に収まらない場合は長い列を切り詰める
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"io"
+	"strings"
+)
+
+// 文字色
+type Color int
+
+const (
+	ColorNone Color = iota
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorGray
+)
+
+var colorCodes = map[Color]string{
+	ColorRed:    "31",
+	ColorGreen:  "32",
+	ColorYellow: "33",
+	ColorBlue:   "34",
+	ColorGray:   "90",
+}
+
+// 文字列にANSIエスケープシーケンスで色を付ける
+func Colorize(s string, c Color) string {
+	code, ok := colorCodes[c]
+	if !ok {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// 列の間の空白の数
+const tableSpacing = 2
+
+// 切り詰める場合でも、これより短くはしない
+const tableMinColumnWidth = 6
+
+// 切り詰めた場合に末尾に付ける文字列
+const tableEllipsis = "…"
+
+type TableOptions struct {
+	// 表の最大幅。0の場合は切り詰めない
+	MaxWidth int
+
+	// 切り詰めない列(IDなど)
+	FixedColumns []int
+
+	// 色を付ける場合はtrue
+	Color bool
+
+	// セルの色を返す関数。nilの場合はヘッダのみ強調する
+	CellColor func(row int, col int) Color
+}
+
+// tableを表示幅で揃えて出力する
+func RenderTable(w io.Writer, table *Table, opts *TableOptions) error {
+	if opts == nil {
+		opts = &TableOptions{}
+	}
+
+	widths := tableColumnWidths(table)
+	if opts.MaxWidth > 0 {
+		shrinkColumns(widths, opts.MaxWidth-tableSpacing*(len(widths)-1), opts.FixedColumns)
+	}
+
+	lines := make([]string, 0, len(table.Rows)+1)
+	lines = append(lines, tableLine(table.Header, widths, func(col int) Color {
+		return ColorNone
+	}))
+	if opts.Color {
+		lines[0] = "\x1b[1m" + lines[0] + "\x1b[0m"
+	}
+
+	for i, row := range table.Rows {
+		lines = append(lines, tableLine(row, widths, func(col int) Color {
+			if !opts.Color || opts.CellColor == nil {
+				return ColorNone
+			}
+			return opts.CellColor(i, col)
+		}))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 列ごとの表示幅を返す
+func tableColumnWidths(table *Table) []int {
+	widths := make([]int, len(table.Header))
+	for i, h := range table.Header {
+		widths[i] = runewidth.StringWidth(h)
+	}
+
+	for _, row := range table.Rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				break
+			}
+			if w := runewidth.StringWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return widths
+}
+
+// 列の幅の合計がmaxに収まるまで、一番長い列から切り詰める
+func shrinkColumns(widths []int, max int, fixed []int) {
+	isFixed := map[int]bool{}
+	for _, col := range fixed {
+		isFixed[col] = true
+	}
+
+	for {
+		total, widest := 0, -1
+		for i, w := range widths {
+			total += w
+			if !isFixed[i] && w > tableMinColumnWidth && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+
+		if total <= max || widest < 0 {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// 1行分を整形する。最後の列は空白で埋めない
+func tableLine(cells []string, widths []int, color func(col int) Color) string {
+	parts := make([]string, 0, len(widths))
+	for i, width := range widths {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+
+		if runewidth.StringWidth(cell) > width {
+			cell = runewidth.Truncate(cell, width, tableEllipsis)
+		}
+
+		padding := ""
+		if i < len(widths)-1 {
+			padding = strings.Repeat(" ", width-runewidth.StringWidth(cell))
+		}
+
+		parts = append(parts, Colorize(cell, color(i))+padding)
+	}
+	return strings.TrimRight(strings.Join(parts, strings.Repeat(" ", tableSpacing)), " ")
+}
+
+// 表示幅がwidthになるまでsの末尾に空白を追加する
+func PadRight(s string, width int) string {
+	if w := runewidth.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
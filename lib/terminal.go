@@ -0,0 +1,182 @@
+package lib
+
+// 端末の判定と操作
+
+import (
+	"errors"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// --color で指定できる値
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// fが端末であればtrueを返す
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// 端末の幅を返す。端末でない場合は0を返す
+func TerminalWidth(f *os.File) int {
+	if !IsTerminal(f) {
+		return 0
+	}
+
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// --color の値が正しいかをチェックする
+func ValidateColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return nil
+	}
+	return errors.New(T(`Undefined color mode "%s". It should be "auto", "always" or "never".`, mode))
+}
+
+// 色を付けるかどうかを返す
+// autoの場合は、fが端末で、環境変数NO_COLORが設定されていなければ色を付ける
+func UseColor(mode string, f *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && IsTerminal(f)
+}
+
+// 端末をrawモードにする
+// 戻り値の関数を呼ぶと元のモードに戻る
+func MakeRaw(f *os.File) (restore func(), err error) {
+	state, err := term.MakeRaw(int(f.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return func() { term.Restore(int(f.Fd()), state) }, nil
+}
+
+// 端末のサイズ(幅、高さ)を返す。端末でない場合は0を返す
+func TerminalSize(f *os.File) (width int, height int) {
+	if !IsTerminal(f) {
+		return 0, 0
+	}
+
+	width, height, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// rawモードで入力されたキー
+// 文字の場合はRune、それ以外はCodeに値が入る
+type Key struct {
+	Rune rune
+	Code KeyCode
+}
+
+type KeyCode int
+
+const (
+	KeyNone KeyCode = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyTab
+	KeyCtrlC
+	KeyCtrlU
+	KeyCtrlW
+	KeyCtrlA
+	KeyCtrlD
+	KeyCtrlE
+	KeyCtrlK
+	KeyHome
+	KeyEnd
+	KeyDelete
+)
+
+// rからキー入力を読む
+// 一度に読んだバイト列に含まれるキーを全て返す
+func ReadKeys(r io.Reader) ([]Key, error) {
+	buf := make([]byte, 256)
+	n, err := r.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(buf[:n]), nil
+}
+
+// バイト列をキー入力に変換する
+func ParseKeys(b []byte) []Key {
+	keys := []Key{}
+	for len(b) > 0 {
+		// Home、End、Delete(ESC [ 3 ~ など)
+		if len(b) >= 4 && b[0] == 0x1b && b[1] == '[' && b[3] == '~' {
+			code := map[byte]KeyCode{'1': KeyHome, '3': KeyDelete, '4': KeyEnd, '7': KeyHome, '8': KeyEnd}[b[2]]
+			if code != KeyNone {
+				keys = append(keys, Key{Code: code})
+			}
+			b = b[4:]
+			continue
+		}
+
+		// カーソルキー、Home、End(ESC [ A など)
+		if len(b) >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O') {
+			code := map[byte]KeyCode{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft, 'H': KeyHome, 'F': KeyEnd}[b[2]]
+			if code != KeyNone {
+				keys = append(keys, Key{Code: code})
+			}
+			b = b[3:]
+			continue
+		}
+
+		switch b[0] {
+		case 0x1b:
+			keys = append(keys, Key{Code: KeyEsc})
+		case '\r', '\n':
+			keys = append(keys, Key{Code: KeyEnter})
+		case 0x7f, 0x08:
+			keys = append(keys, Key{Code: KeyBackspace})
+		case '\t':
+			keys = append(keys, Key{Code: KeyTab})
+		case 0x03:
+			keys = append(keys, Key{Code: KeyCtrlC})
+		case 0x15:
+			keys = append(keys, Key{Code: KeyCtrlU})
+		case 0x17:
+			keys = append(keys, Key{Code: KeyCtrlW})
+		case 0x01:
+			keys = append(keys, Key{Code: KeyCtrlA})
+		case 0x04:
+			keys = append(keys, Key{Code: KeyCtrlD})
+		case 0x05:
+			keys = append(keys, Key{Code: KeyCtrlE})
+		case 0x0b:
+			keys = append(keys, Key{Code: KeyCtrlK})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= 0x20 {
+				keys = append(keys, Key{Rune: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/hironobu-s/conoha-vps/command"
 	"github.com/hironobu-s/conoha-vps/lib"
 	"os"
+	"time"
 )
 
 func main() {
@@ -11,6 +12,21 @@ func main() {
 
 	log := lib.GetLogInstance()
 
+	// 全体のオプションは各コマンドに渡す前に取り除いておく
+	// ログの設定は、オプションが指定されていない場合は設定ファイルの値を使う
+	config := &lib.Config{}
+	config.Read()
+
+	opts := command.GetGlobalOptions()
+	if len(os.Args) > 1 && os.Args[1] == command.CompleteCommandName {
+		// 補完では入力中のコマンドラインをそのまま使うので、全体のオプションを取り除かない
+		lib.SetLang(lib.DetectLang())
+
+	} else if os.Args, err = command.ParseGlobalFlags(os.Args, config); err != nil {
+		command.PrintError(os.Stderr, opts.ErrorFormat, err)
+		os.Exit(int(command.ExitCodeOf(err)))
+	}
+
 	var cmd command.Commander
 	var subcommand string = ""
 
@@ -18,59 +34,75 @@ func main() {
 		subcommand = os.Args[1]
 	}
 
-	log.Debugf("starting %s subcommand...", subcommand)
-
-	switch subcommand {
-	case "login":
-		cmd = command.NewLogin()
-	case "stat":
-		cmd = command.NewVpsStat()
-	case "list":
-		cmd = command.NewVpsList()
-	case "add":
-		cmd = command.NewVpsAdd()
-	case "remove":
-		cmd = command.NewVpsRemove()
-	case "label":
-		cmd = command.NewVpsLabel()
-	case "power":
-		cmd = command.NewVpsPower()
-	case "ssh-key":
-		cmd = command.NewSshKey()
-	case "ssh":
-		cmd = command.NewSsh()
-	case "logout":
-		cmd = command.NewLogout()
-	case "version":
-		cmd = command.NewVersion()
-	default:
+	// 組み込みのコマンドがない場合はPATHにあるプラグイン(conoha-xxx)を探す
+	info := command.LookupCommand(subcommand)
+	if info == nil {
+		info = command.LookupPlugin(subcommand)
+	}
+	if info != nil {
+		// 別名の場合もログには正式なコマンド名を出力する
+		subcommand = info.Name
+		cmd = info.New()
+	} else {
 		cmd = command.NewNocommand()
 	}
 	defer cmd.Shutdown()
 
-	// login, logout, version, nocommand以外はログインが必須
-	_, nocommand := cmd.(*command.Nocommand)
-	if subcommand != "login" && subcommand != "version" && subcommand != "logout" && !nocommand {
+	lib.AddLogField("command", subcommand)
+	started = time.Now()
+	log.Debugf("starting %s subcommand...", subcommand)
+
+	if info != nil && info.RequireLogin {
 		l := command.NewLogin()
 
-		loggedIn, _ := l.LoggedIn()
+		// 通信のエラーはログインの失敗にしない
+		loggedIn, err := l.LoggedIn()
+		if err != nil {
+			exit(cmd, opts.ErrorFormat, err)
+		}
 		if !loggedIn {
 			log.Debugf("Session is timed out. try relogin...")
 
 			// 再ログイン
 			loggedIn, err = l.Relogin()
-			if !loggedIn {
-				log.Errorf("Session is timed out. Please log in.")
-				return
+			if err != nil {
+				exit(cmd, opts.ErrorFormat, err)
+			} else if !loggedIn {
+				exit(cmd, opts.ErrorFormat, &command.AuthError{})
 			}
 		}
 	}
 
 	if err = cmd.Run(); err != nil {
-		// ShowUsageErrorの場合はUsage()を表示してるだけなのでログは表示しない
-		_, ok := err.(*command.ShowUsageError)
-		if !ok {
-			log.Error(err)
-		}
+		exit(cmd, opts.ErrorFormat, err)
+	}
+	logFinished(nil)
+}
+
+// コマンドの開始時刻
+var started time.Time
+
+// コマンドの終了をログに出力する
+func logFinished(err error) {
+	lib.GetLogInstance().WithFields(map[string]interface{}{
+		"duration":  time.Since(started).String(),
+		"exit_code": int(command.ExitCodeOf(err)),
+	}).Debug("finished")
+}
+
+// エラーを出力して、エラーに対応する終了コードで終了する
+func exit(cmd command.Commander, errorFormat string, err error) {
+	// ShowUsageErrorの場合はUsage()を表示してるだけなのでエラーは表示しない
+	// プラグインのエラーはプラグインが表示している
+	switch err.(type) {
+	case *command.ShowUsageError, *command.PluginExitError:
+	default:
+		command.PrintError(os.Stderr, errorFormat, err)
 	}
+
+	logFinished(err)
+
+	// os.Exit()はdeferを実行しないので、先にShutdown()を呼んでおく
+	cmd.Shutdown()
+	os.Exit(int(command.ExitCodeOf(err)))
 }